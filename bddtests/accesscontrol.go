@@ -0,0 +1,114 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+)
+
+// minDeniedStatus is the minimum chaincode status code which indicates that access was denied
+// (i.e. any status >= 400 returned by the chaincode, such as 403 or the 500 returned by shim.Error)
+const minDeniedStatus = 400
+
+func (d *CommonSteps) enrollUserWithAttributes(name, orgID, attributes string) error {
+	if err := d.registerUserWithCAAndAttributes(name, orgID, "client", "", attributes); err != nil {
+		return err
+	}
+	return d.enrollUserWithCA(name, orgID)
+}
+
+func (d *CommonSteps) invokeCCAsUserIsGranted(ccID, args, channelID, userType, orgID string) error {
+	return checkAccessGranted(d.invokeCCAsUserWithStatus(ccID, args, channelID, userType, orgID))
+}
+
+func (d *CommonSteps) invokeCCAsUserIsDenied(ccID, args, channelID, userType, orgID string) error {
+	return checkAccessDenied(d.invokeCCAsUserWithStatus(ccID, args, channelID, userType, orgID))
+}
+
+func (d *CommonSteps) queryCCAsUserIsGranted(ccID, args, channelID, userType, orgID string) error {
+	return checkAccessGranted(d.queryCCAsUserWithError(ccID, args, channelID, userType, orgID))
+}
+
+func (d *CommonSteps) queryCCAsUserIsDenied(ccID, args, channelID, userType, orgID string) error {
+	return checkAccessDenied(d.queryCCAsUserWithError(ccID, args, channelID, userType, orgID))
+}
+
+// invokeCCAsUserWithStatus invokes the chaincode as the given user and returns the error returned by the SDK,
+// or an error containing the chaincode status if the status indicates a failure.
+func (d *CommonSteps) invokeCCAsUserWithStatus(ccID, args, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.InvokeCCWithArgsAsUser(ccID, channelID, nil, argArr, nil, orgID, userType)
+	if err != nil {
+		return err
+	}
+
+	d.BDDContext.Vars().SetResponse(string(resp.Payload))
+
+	if resp.ChaincodeStatus >= minDeniedStatus {
+		return status.New(status.ChaincodeStatus, resp.ChaincodeStatus, string(resp.Payload), nil)
+	}
+	return nil
+}
+
+func (d *CommonSteps) queryCCAsUserWithError(ccID, args, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	response, err := d.QueryCCAsUser(ccID, channelID, argArr, nil, orgID, userType)
+	if err != nil {
+		return err
+	}
+
+	d.BDDContext.Vars().SetResponse(response)
+	return nil
+}
+
+func checkAccessGranted(err error) error {
+	if err != nil {
+		return errors.WithMessage(err, "expecting access to be granted")
+	}
+	return nil
+}
+
+func checkAccessDenied(err error) error {
+	if err == nil {
+		return errors.New("expecting access to be denied but the request succeeded")
+	}
+
+	code, ok := chaincodeStatusCode(err)
+	if !ok {
+		return errors.WithMessage(err, "expecting access to be denied by the chaincode but got an unrelated error")
+	}
+
+	if code < minDeniedStatus {
+		return errors.Errorf("expecting access to be denied but got chaincode status %d", code)
+	}
+
+	logger.Infof("Access was denied with chaincode status %d: %s", code, err)
+	return nil
+}
+
+// chaincodeStatusCode returns the chaincode status code within the given error. If the error
+// contains multiple errors (from multiple endorsers) then the first chaincode status is returned.
+func chaincodeStatusCode(err error) (int32, bool) {
+	s, ok := findStatus(err, func(s *status.Status) bool { return s.Group == status.ChaincodeStatus })
+	if !ok {
+		return 0, false
+	}
+	return s.Code, true
+}
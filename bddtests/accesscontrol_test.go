@@ -0,0 +1,32 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAccess(t *testing.T) {
+	denied := errors.WithMessage(status.New(status.ChaincodeStatus, 403, "access denied", nil), "InvokeChaincode return error")
+	failed := status.New(status.ChaincodeStatus, 200, "ok", nil)
+	unrelated := status.New(status.EndorserClientStatus, int32(status.Timeout), "timeout", nil)
+
+	assert.NoError(t, checkAccessGranted(nil))
+	assert.Error(t, checkAccessGranted(denied))
+
+	assert.NoError(t, checkAccessDenied(denied))
+	assert.NoError(t, checkAccessDenied(multi.Errors{unrelated, denied}))
+	assert.Error(t, checkAccessDenied(nil))
+	assert.Error(t, checkAccessDenied(failed))
+	assert.Error(t, checkAccessDenied(unrelated))
+	assert.Error(t, checkAccessDenied(errors.New("some error")))
+}
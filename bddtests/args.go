@@ -0,0 +1,211 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+
+	"github.com/DATA-DOG/godog/gherkin"
+	"github.com/pkg/errors"
+)
+
+const (
+	base64ArgPrefix = "b64:"
+	hexArgPrefix    = "hex:"
+	fileArgPrefix   = "file:"
+)
+
+// SplitArgs splits the given comma-separated args. The following are not treated as separators:
+//
+//   - an escaped comma (\,)
+//   - a comma within an arg that is enclosed in single or double quotes, e.g. 'a,b' (the enclosing quotes are removed)
+//   - a comma within braces or brackets, e.g. {"a":1,"b":2} or ${json(var1, "path")}
+//
+// Outside of quotes, braces and brackets a backslash is only treated as an escape when it precedes a comma, so
+// that args such as C:\path are unchanged. Within quotes a backslash may be used to escape the quote or another
+// backslash. Within braces and brackets the text is retained verbatim so that JSON documents are unchanged.
+// A brace or bracket which is not closed is treated as a regular character.
+func SplitArgs(args string) []string {
+	runes := []rune(args)
+	unclosed := make(map[int]bool)
+	for {
+		result, openIndex := splitArgs(runes, unclosed)
+		if openIndex < 0 {
+			return result
+		}
+
+		// Split again, treating the unclosed brace or bracket as a regular character
+		unclosed[openIndex] = true
+	}
+}
+
+// splitArgs splits the given args, treating the braces and brackets at the given (unclosed) indexes as regular
+// characters. If a brace or bracket is not closed then the index of the outermost unclosed brace or bracket is
+// returned; otherwise -1 is returned.
+func splitArgs(runes []rune, unclosed map[int]bool) ([]string, int) {
+	var result []string
+	var current strings.Builder
+
+	depth := 0
+	openIndex := -1
+	inString := false
+	var quote rune
+	fieldStart := true
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case quote != 0:
+			// Within a quoted arg
+			switch {
+			case c == '\\' && i+1 < len(runes):
+				i++
+				current.WriteRune(runes[i])
+			case c == quote:
+				quote = 0
+			default:
+				current.WriteRune(c)
+			}
+
+		case depth > 0:
+			// Within braces or brackets (e.g. a JSON document) - retain everything verbatim
+			current.WriteRune(c)
+			switch {
+			case inString && c == '\\' && i+1 < len(runes):
+				i++
+				current.WriteRune(runes[i])
+			case c == '"':
+				inString = !inString
+			case !inString && (c == '{' || c == '[') && !unclosed[i]:
+				depth++
+			case !inString && (c == '}' || c == ']'):
+				depth--
+			}
+
+		case c == '\\' && i+1 < len(runes) && runes[i+1] == ',':
+			i++
+			current.WriteRune(',')
+
+		case fieldStart && (c == '\'' || c == '"'):
+			quote = c
+
+		case (c == '{' || c == '[') && !unclosed[i]:
+			depth++
+			openIndex = i
+			inString = false
+			current.WriteRune(c)
+
+		case c == ',':
+			result = append(result, current.String())
+			current.Reset()
+			fieldStart = true
+			continue
+
+		default:
+			current.WriteRune(c)
+		}
+
+		fieldStart = false
+	}
+
+	if depth > 0 {
+		return nil, openIndex
+	}
+
+	return append(result, current.String()), -1
+}
+
+// DocStringArgs returns the args contained in the given DocString. The content is split using SplitArgs and
+// leading and trailing white space (including new lines) is removed from each arg.
+func DocStringArgs(doc *gherkin.DocString) []string {
+	if doc == nil {
+		return nil
+	}
+
+	var args []string
+	for _, arg := range SplitArgs(strings.TrimSpace(doc.Content)) {
+		args = append(args, strings.TrimSpace(arg))
+	}
+	return args
+}
+
+// DataTableArgs returns the values of all of the cells in the given DataTable, row by row. Each cell is a
+// single arg and is not split any further.
+func DataTableArgs(table *gherkin.DataTable) []string {
+	if table == nil {
+		return nil
+	}
+
+	var args []string
+	for _, row := range table.Rows {
+		for _, cell := range row.Cells {
+			args = append(args, cell.Value)
+		}
+	}
+	return args
+}
+
+// DecodeArgs converts the given args to bytes. Args with the following prefixes are decoded:
+//
+//	b64:<base64-encoded value> - the value is decoded from (standard) base64
+//	hex:<hex-encoded value>    - the value is decoded from hex
+//	file:<path>                - the contents of the file at the given path
+//
+// All other args are converted to bytes as is.
+func DecodeArgs(args []string) ([][]byte, error) {
+	byteArgs := make([][]byte, len(args))
+	for i, arg := range args {
+		b, err := DecodeArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		byteArgs[i] = b
+	}
+	return byteArgs, nil
+}
+
+// DecodeArg converts the given arg to bytes (see DecodeArgs)
+func DecodeArg(arg string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(arg, base64ArgPrefix):
+		b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, base64ArgPrefix))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid base64 arg [%s]", arg)
+		}
+		return b, nil
+	case strings.HasPrefix(arg, hexArgPrefix):
+		b, err := hex.DecodeString(strings.TrimPrefix(arg, hexArgPrefix))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid hex arg [%s]", arg)
+		}
+		return b, nil
+	case strings.HasPrefix(arg, fileArgPrefix):
+		b, err := ioutil.ReadFile(strings.TrimPrefix(arg, fileArgPrefix))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading file for arg [%s]", arg)
+		}
+		return b, nil
+	default:
+		return []byte(arg), nil
+	}
+}
+
+// EncodeValue encodes the given value using the given encoding (base64 or hex)
+func EncodeValue(value []byte, encoding string) (string, error) {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.EncodeToString(value), nil
+	case "hex":
+		return hex.EncodeToString(value), nil
+	default:
+		return "", errors.Errorf("unsupported encoding [%s]", encoding)
+	}
+}
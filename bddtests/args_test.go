@@ -0,0 +1,100 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/godog/gherkin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	assert.Equal(t, []string{""}, SplitArgs(""))
+	assert.Equal(t, []string{"put", "key1", "value1"}, SplitArgs("put,key1,value1"))
+	assert.Equal(t, []string{"put", "", "value1"}, SplitArgs("put,,value1"))
+
+	t.Run("Escaped comma", func(t *testing.T) {
+		assert.Equal(t, []string{"put", "key1", "a,b"}, SplitArgs(`put,key1,a\,b`))
+		assert.Equal(t, []string{`a\\b`}, SplitArgs(`a\\b`))
+		assert.Equal(t, []string{`C:\path`, `x\`}, SplitArgs(`C:\path,x\`))
+	})
+
+	t.Run("Quoted", func(t *testing.T) {
+		assert.Equal(t, []string{"put", "key1", "a,b"}, SplitArgs(`put,key1,'a,b'`))
+		assert.Equal(t, []string{"put", "key1", "a,'b'"}, SplitArgs(`put,key1,"a,'b'"`))
+		assert.Equal(t, []string{"it's"}, SplitArgs(`'it\'s'`))
+		assert.Equal(t, []string{"don't", "x"}, SplitArgs(`don't,x`))
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		assert.Equal(t, []string{"put", "key1", `{"a":1,"b":[1,2],"c":"x}],\"y"}`}, SplitArgs(`put,key1,{"a":1,"b":[1,2],"c":"x}],\"y"}`))
+		assert.Equal(t, []string{"put", `[1,2]`, "x"}, SplitArgs(`put,[1,2],x`))
+	})
+
+	t.Run("Unbalanced brackets", func(t *testing.T) {
+		assert.Equal(t, []string{"put", "[1", "2", "x"}, SplitArgs(`put,[1,2,x`))
+		assert.Equal(t, []string{"{a", "[1,2]", "x"}, SplitArgs(`{a,[1,2],x`))
+		assert.Equal(t, []string{"[x", "{a", "b"}, SplitArgs(`[x,{a,b`))
+		assert.Equal(t, []string{`{"a":[1,2}`, "x"}, SplitArgs(`{"a":[1,2},x`))
+	})
+
+	t.Run("Variables", func(t *testing.T) {
+		assert.Equal(t, []string{"put", "${arr[0]}", `${json(var1, "a.b")}`}, SplitArgs(`put,${arr[0]},${json(var1, "a.b")}`))
+	})
+}
+
+func TestDocStringAndDataTableArgs(t *testing.T) {
+	doc := &gherkin.DocString{Content: "put,\nkey1,\n{\n  \"a\": 1,\n  \"b\": 2\n}\n"}
+	assert.Equal(t, []string{"put", "key1", "{\n  \"a\": 1,\n  \"b\": 2\n}"}, DocStringArgs(doc))
+	assert.Nil(t, DocStringArgs(nil))
+
+	table := &gherkin.DataTable{
+		Rows: []*gherkin.TableRow{
+			{Cells: []*gherkin.TableCell{{Value: "put"}}},
+			{Cells: []*gherkin.TableCell{{Value: "key1"}, {Value: `{"a":1,"b":2}`}}},
+		},
+	}
+	assert.Equal(t, []string{"put", "key1", `{"a":1,"b":2}`}, DataTableArgs(table))
+	assert.Nil(t, DataTableArgs(nil))
+}
+
+func TestDecodeArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "payload.bin")
+	assert.NoError(t, ioutil.WriteFile(file, []byte{0x0a, 0x00, 0xff}, 0644))
+
+	args, err := DecodeArgs([]string{"put", "b64:AAEC", "hex:0a0bff", "file:" + file})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("put"), {0x00, 0x01, 0x02}, {0x0a, 0x0b, 0xff}, {0x0a, 0x00, 0xff}}, args)
+
+	_, err = DecodeArgs([]string{"b64:!!"})
+	assert.Error(t, err)
+	_, err = DecodeArgs([]string{"hex:xyz"})
+	assert.Error(t, err)
+	_, err = DecodeArgs([]string{"file:" + filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestEncodeValue(t *testing.T) {
+	v, err := EncodeValue([]byte{0x00, 0x01, 0x02}, "base64")
+	assert.NoError(t, err)
+	assert.Equal(t, "AAEC", v)
+
+	v, err = EncodeValue([]byte{0x0a, 0x0b, 0xff}, "hex")
+	assert.NoError(t, err)
+	assert.Equal(t, "0a0bff", v)
+
+	_, err = EncodeValue(nil, "base32")
+	assert.Error(t, err)
+}
@@ -0,0 +1,364 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// Block contains the decoded contents of a block
+type Block struct {
+	Number       uint64         `json:"number"`
+	PreviousHash string         `json:"previous_hash"`
+	DataHash     string         `json:"data_hash"`
+	Transactions []*Transaction `json:"transactions"`
+	Metadata     *BlockMetadata `json:"metadata"`
+}
+
+// BlockMetadata contains the decoded block metadata
+type BlockMetadata struct {
+	LastConfig      uint64   `json:"last_config"`
+	ValidationCodes []string `json:"validation_codes"`
+	Signers         []string `json:"signers,omitempty"`
+}
+
+// Transaction contains the decoded contents of a transaction envelope
+type Transaction struct {
+	TxID           string      `json:"tx_id"`
+	Type           string      `json:"type"`
+	ChannelID      string      `json:"channel_id"`
+	Timestamp      string      `json:"timestamp,omitempty"`
+	Creator        string      `json:"creator,omitempty"`
+	ValidationCode string      `json:"validation_code"`
+	Actions        []*TxAction `json:"actions,omitempty"`
+}
+
+// TxAction contains the decoded contents of a chaincode action within an endorser transaction
+type TxAction struct {
+	ChaincodeID      string          `json:"chaincode_id"`
+	ChaincodeVersion string          `json:"chaincode_version,omitempty"`
+	Args             []string        `json:"args,omitempty"`
+	Endorsers        []string        `json:"endorsers,omitempty"`
+	Response         *TxResponse     `json:"response,omitempty"`
+	Event            *ChaincodeEvent `json:"event,omitempty"`
+	RWSets           []*NsRWSet      `json:"rw_sets,omitempty"`
+}
+
+// TxResponse contains the chaincode response of a chaincode action
+type TxResponse struct {
+	Status  int32  `json:"status"`
+	Message string `json:"message,omitempty"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// ChaincodeEvent contains a chaincode event that was set by a chaincode action
+type ChaincodeEvent struct {
+	ChaincodeID string `json:"chaincode_id"`
+	TxID        string `json:"tx_id"`
+	EventName   string `json:"event_name"`
+	Payload     string `json:"payload,omitempty"`
+}
+
+// NsRWSet contains the public reads and writes of a chaincode action for a given namespace
+type NsRWSet struct {
+	Namespace string     `json:"namespace"`
+	Reads     []*KVRead  `json:"reads,omitempty"`
+	Writes    []*KVWrite `json:"writes,omitempty"`
+}
+
+// KVRead is a key that was read by a chaincode action
+type KVRead struct {
+	Key     string `json:"key"`
+	Version string `json:"version,omitempty"`
+}
+
+// KVWrite is a key that was written by a chaincode action
+type KVWrite struct {
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	IsDelete bool   `json:"is_delete,omitempty"`
+}
+
+// DecodeBlock decodes the given block into a Block
+func DecodeBlock(block *common.Block) (*Block, error) {
+	if block.Header == nil {
+		return nil, errors.New("block header is nil")
+	}
+
+	b := &Block{
+		Number:       block.Header.Number,
+		PreviousHash: hex.EncodeToString(block.Header.PreviousHash),
+		DataHash:     hex.EncodeToString(block.Header.DataHash),
+	}
+
+	metadata, err := decodeBlockMetadata(block.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	b.Metadata = metadata
+
+	if block.Data == nil {
+		return b, nil
+	}
+
+	for i, envBytes := range block.Data.Data {
+		tx, err := decodeTransaction(envBytes)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error decoding transaction %d in block %d", i, b.Number)
+		}
+		if i < len(metadata.ValidationCodes) {
+			tx.ValidationCode = metadata.ValidationCodes[i]
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+
+	return b, nil
+}
+
+func decodeBlockMetadata(metadata *common.BlockMetadata) (*BlockMetadata, error) {
+	m := &BlockMetadata{}
+	if metadata == nil {
+		return m, nil
+	}
+
+	if len(metadata.Metadata) > int(common.BlockMetadataIndex_SIGNATURES) {
+		sigMD := &common.Metadata{}
+		if err := proto.Unmarshal(metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], sigMD); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling signatures metadata")
+		}
+
+		// Fabric 2.x stores the last config index in the signatures metadata
+		ordererMD := &common.OrdererBlockMetadata{}
+		if err := proto.Unmarshal(sigMD.Value, ordererMD); err == nil && ordererMD.LastConfig != nil {
+			m.LastConfig = ordererMD.LastConfig.Index
+		}
+
+		for _, sig := range sigMD.Signatures {
+			shdr := &common.SignatureHeader{}
+			if err := proto.Unmarshal(sig.SignatureHeader, shdr); err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling signature header")
+			}
+			m.Signers = append(m.Signers, creatorMSPID(shdr.Creator))
+		}
+	}
+
+	if len(metadata.Metadata) > int(common.BlockMetadataIndex_LAST_CONFIG) && len(metadata.Metadata[common.BlockMetadataIndex_LAST_CONFIG]) > 0 {
+		lastConfigMD := &common.Metadata{}
+		if err := proto.Unmarshal(metadata.Metadata[common.BlockMetadataIndex_LAST_CONFIG], lastConfigMD); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling last config metadata")
+		}
+		lastConfig := &common.LastConfig{}
+		if err := proto.Unmarshal(lastConfigMD.Value, lastConfig); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling last config")
+		}
+		if lastConfig.Index > m.LastConfig {
+			m.LastConfig = lastConfig.Index
+		}
+	}
+
+	if len(metadata.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		for _, code := range metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] {
+			m.ValidationCodes = append(m.ValidationCodes, pb.TxValidationCode(code).String())
+		}
+	}
+
+	return m, nil
+}
+
+func decodeTransaction(envBytes []byte) (*Transaction, error) {
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(envBytes, env); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling envelope")
+	}
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling payload")
+	}
+
+	if payload.Header == nil {
+		return nil, errors.New("payload header is nil")
+	}
+
+	chdr := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, chdr); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling channel header")
+	}
+
+	shdr := &common.SignatureHeader{}
+	if err := proto.Unmarshal(payload.Header.SignatureHeader, shdr); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling signature header")
+	}
+
+	tx := &Transaction{
+		TxID:      chdr.TxId,
+		Type:      common.HeaderType(chdr.Type).String(),
+		ChannelID: chdr.ChannelId,
+		Creator:   creatorMSPID(shdr.Creator),
+	}
+
+	if chdr.Timestamp != nil {
+		if t, err := ptypes.Timestamp(chdr.Timestamp); err == nil {
+			tx.Timestamp = t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return tx, nil
+	}
+
+	ptx := &pb.Transaction{}
+	if err := proto.Unmarshal(payload.Data, ptx); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling transaction")
+	}
+
+	for _, action := range ptx.Actions {
+		a, err := decodeTxAction(action)
+		if err != nil {
+			return nil, err
+		}
+		tx.Actions = append(tx.Actions, a)
+	}
+
+	return tx, nil
+}
+
+func decodeTxAction(action *pb.TransactionAction) (*TxAction, error) {
+	ccActionPayload := &pb.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(action.Payload, ccActionPayload); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode action payload")
+	}
+
+	a := &TxAction{}
+
+	ccProposalPayload := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(ccActionPayload.ChaincodeProposalPayload, ccProposalPayload); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode proposal payload")
+	}
+
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(ccProposalPayload.Input, cis); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode invocation spec")
+	}
+	if cis.ChaincodeSpec != nil && cis.ChaincodeSpec.Input != nil {
+		for _, arg := range cis.ChaincodeSpec.Input.Args {
+			a.Args = append(a.Args, string(arg))
+		}
+	}
+
+	if ccActionPayload.Action == nil {
+		return a, nil
+	}
+
+	for _, endorsement := range ccActionPayload.Action.Endorsements {
+		a.Endorsers = append(a.Endorsers, creatorMSPID(endorsement.Endorser))
+	}
+
+	prp := &pb.ProposalResponsePayload{}
+	if err := proto.Unmarshal(ccActionPayload.Action.ProposalResponsePayload, prp); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling proposal response payload")
+	}
+
+	ccAction := &pb.ChaincodeAction{}
+	if err := proto.Unmarshal(prp.Extension, ccAction); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode action")
+	}
+
+	if ccAction.ChaincodeId != nil {
+		a.ChaincodeID = ccAction.ChaincodeId.Name
+		a.ChaincodeVersion = ccAction.ChaincodeId.Version
+	}
+
+	if ccAction.Response != nil {
+		a.Response = &TxResponse{
+			Status:  ccAction.Response.Status,
+			Message: ccAction.Response.Message,
+			Payload: string(ccAction.Response.Payload),
+		}
+	}
+
+	if len(ccAction.Events) > 0 {
+		event := &pb.ChaincodeEvent{}
+		if err := proto.Unmarshal(ccAction.Events, event); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling chaincode event")
+		}
+		if event.EventName != "" {
+			a.Event = &ChaincodeEvent{
+				ChaincodeID: event.ChaincodeId,
+				TxID:        event.TxId,
+				EventName:   event.EventName,
+				Payload:     string(event.Payload),
+			}
+		}
+	}
+
+	rwSets, err := decodeRWSets(ccAction.Results)
+	if err != nil {
+		return nil, err
+	}
+	a.RWSets = rwSets
+
+	return a, nil
+}
+
+func decodeRWSets(results []byte) ([]*NsRWSet, error) {
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(results, txRWSet); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling read-write set")
+	}
+
+	var nsRWSets []*NsRWSet
+	for _, nsRWSet := range txRWSet.NsRwset {
+		kvRWSet := &kvrwset.KVRWSet{}
+		if err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet); err != nil {
+			return nil, errors.Wrapf(err, "error unmarshalling KV read-write set for namespace [%s]", nsRWSet.Namespace)
+		}
+
+		ns := &NsRWSet{Namespace: nsRWSet.Namespace}
+		for _, r := range kvRWSet.Reads {
+			read := &KVRead{Key: r.Key}
+			if r.Version != nil {
+				read.Version = versionString(r.Version)
+			}
+			ns.Reads = append(ns.Reads, read)
+		}
+		for _, w := range kvRWSet.Writes {
+			ns.Writes = append(ns.Writes, &KVWrite{Key: w.Key, Value: string(w.Value), IsDelete: w.IsDelete})
+		}
+		nsRWSets = append(nsRWSets, ns)
+	}
+
+	return nsRWSets, nil
+}
+
+func versionString(v *kvrwset.Version) string {
+	return fmt.Sprintf("%d:%d", v.BlockNum, v.TxNum)
+}
+
+func creatorMSPID(creator []byte) string {
+	sid := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(creator, sid); err != nil {
+		logger.Debugf("Error unmarshalling serialized identity: %s", err)
+		return ""
+	}
+	return sid.Mspid
+}
@@ -0,0 +1,210 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBlock(t *testing.T) {
+	block := newMockBlock(t, 5, "tx1", "cc1", pb.TxValidationCode_MVCC_READ_CONFLICT)
+
+	b, err := DecodeBlock(block)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(5), b.Number)
+	assert.Equal(t, "0102", b.PreviousHash)
+	assert.Equal(t, "0304", b.DataHash)
+	assert.Equal(t, []string{"MVCC_READ_CONFLICT"}, b.Metadata.ValidationCodes)
+	assert.Equal(t, uint64(2), b.Metadata.LastConfig)
+
+	require.Len(t, b.Transactions, 1)
+	tx := b.Transactions[0]
+	assert.Equal(t, "tx1", tx.TxID)
+	assert.Equal(t, "ENDORSER_TRANSACTION", tx.Type)
+	assert.Equal(t, "mychannel", tx.ChannelID)
+	assert.Equal(t, "Org1MSP", tx.Creator)
+	assert.Equal(t, "MVCC_READ_CONFLICT", tx.ValidationCode)
+
+	require.Len(t, tx.Actions, 1)
+	action := tx.Actions[0]
+	assert.Equal(t, "cc1", action.ChaincodeID)
+	assert.Equal(t, "v1", action.ChaincodeVersion)
+	assert.Equal(t, []string{"put", "key1", "value1"}, action.Args)
+	assert.Equal(t, []string{"Org1MSP"}, action.Endorsers)
+	require.NotNil(t, action.Response)
+	assert.Equal(t, int32(200), action.Response.Status)
+	require.NotNil(t, action.Event)
+	assert.Equal(t, "event1", action.Event.EventName)
+	assert.Equal(t, "payload1", action.Event.Payload)
+
+	require.Len(t, action.RWSets, 1)
+	assert.Equal(t, "cc1", action.RWSets[0].Namespace)
+	require.Len(t, action.RWSets[0].Reads, 1)
+	assert.Equal(t, "key1", action.RWSets[0].Reads[0].Key)
+	assert.Equal(t, "3:1", action.RWSets[0].Reads[0].Version)
+	require.Len(t, action.RWSets[0].Writes, 1)
+	assert.Equal(t, "value1", action.RWSets[0].Writes[0].Value)
+
+	t.Run("No header", func(t *testing.T) {
+		_, err := DecodeBlock(&common.Block{})
+		assert.EqualError(t, err, "block header is nil")
+	})
+
+	t.Run("Invalid data", func(t *testing.T) {
+		_, err := DecodeBlock(&common.Block{Header: &common.BlockHeader{}, Data: &common.BlockData{Data: [][]byte{{0xff}}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestBlockAssertions(t *testing.T) {
+	valid, err := DecodeBlock(newMockBlock(t, 5, "tx1", "cc1", pb.TxValidationCode_VALID))
+	require.NoError(t, err)
+	conflict, err := DecodeBlock(newMockBlock(t, 6, "tx2", "cc2", pb.TxValidationCode_MVCC_READ_CONFLICT))
+	require.NoError(t, err)
+	empty := &Block{Number: 7}
+
+	t.Run("Contains transaction for chaincode", func(t *testing.T) {
+		tests := []struct {
+			block   *Block
+			ccID    string
+			wantErr bool
+		}{
+			{block: valid, ccID: "cc1"},
+			{block: valid, ccID: "cc2", wantErr: true},
+			{block: conflict, ccID: "cc2"},
+			{block: empty, ccID: "cc1", wantErr: true},
+		}
+
+		for _, tc := range tests {
+			err := checkBlockContainsTxForChaincode(tc.block, tc.ccID)
+			assert.Equalf(t, tc.wantErr, err != nil, "block %d, chaincode %s: %v", tc.block.Number, tc.ccID, err)
+		}
+	})
+
+	t.Run("Number of transactions", func(t *testing.T) {
+		tests := []struct {
+			block       *Block
+			expectedNum int
+			wantErr     bool
+		}{
+			{block: valid, expectedNum: 1},
+			{block: valid, expectedNum: 2, wantErr: true},
+			{block: empty, expectedNum: 0},
+			{block: empty, expectedNum: 1, wantErr: true},
+		}
+
+		for _, tc := range tests {
+			err := checkBlockHasNumTransactions(tc.block, tc.expectedNum)
+			assert.Equalf(t, tc.wantErr, err != nil, "block %d, expected %d: %v", tc.block.Number, tc.expectedNum, err)
+		}
+	})
+
+	t.Run("Last transaction validation code", func(t *testing.T) {
+		tests := []struct {
+			block   *Block
+			code    string
+			wantErr bool
+		}{
+			{block: valid, code: "VALID"},
+			{block: valid, code: "MVCC_READ_CONFLICT", wantErr: true},
+			{block: conflict, code: "MVCC_READ_CONFLICT"},
+			{block: empty, code: "VALID", wantErr: true},
+		}
+
+		for _, tc := range tests {
+			err := checkLastTxValidatedWithCode(tc.block, tc.code)
+			assert.Equalf(t, tc.wantErr, err != nil, "block %d, code %s: %v", tc.block.Number, tc.code, err)
+		}
+	})
+
+	t.Run("Block as response", func(t *testing.T) {
+		d := &CommonSteps{BDDContext: &BDDContext{vars: NewVarStore()}}
+		require.NoError(t, d.setBlockAsResponse(conflict))
+
+		assert.NoError(t, d.jsonPathOfCCResponseEquals("number", "6"))
+		assert.NoError(t, d.jsonPathOfCCResponseEquals("transactions.0.tx_id", "tx2"))
+		assert.NoError(t, d.jsonPathOfCCResponseEquals("transactions.0.validation_code", "MVCC_READ_CONFLICT"))
+		assert.NoError(t, d.jsonPathOfCCResponseEquals("transactions.0.actions.0.chaincode_id", "cc2"))
+		assert.NoError(t, d.jsonPathOfCCHasNumItems("transactions.#", 1))
+	})
+}
+
+func newMockBlock(t *testing.T, blockNum uint64, txID, ccID string, code pb.TxValidationCode) *common.Block {
+	creator := marshal(t, &msp.SerializedIdentity{Mspid: "Org1MSP"})
+
+	kvRWSet := marshal(t, &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "key1", Version: &kvrwset.Version{BlockNum: 3, TxNum: 1}}},
+		Writes: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value1")}},
+	})
+
+	ccAction := marshal(t, &pb.ChaincodeAction{
+		ChaincodeId: &pb.ChaincodeID{Name: ccID, Version: "v1"},
+		Response:    &pb.Response{Status: 200},
+		Events:      marshal(t, &pb.ChaincodeEvent{ChaincodeId: ccID, TxId: txID, EventName: "event1", Payload: []byte("payload1")}),
+		Results: marshal(t, &rwset.TxReadWriteSet{
+			NsRwset: []*rwset.NsReadWriteSet{{Namespace: ccID, Rwset: kvRWSet}},
+		}),
+	})
+
+	ccProposalPayload := marshal(t, &pb.ChaincodeProposalPayload{
+		Input: marshal(t, &pb.ChaincodeInvocationSpec{
+			ChaincodeSpec: &pb.ChaincodeSpec{
+				Input: &pb.ChaincodeInput{Args: [][]byte{[]byte("put"), []byte("key1"), []byte("value1")}},
+			},
+		}),
+	})
+
+	tx := marshal(t, &pb.Transaction{
+		Actions: []*pb.TransactionAction{{
+			Payload: marshal(t, &pb.ChaincodeActionPayload{
+				ChaincodeProposalPayload: ccProposalPayload,
+				Action: &pb.ChaincodeEndorsedAction{
+					ProposalResponsePayload: marshal(t, &pb.ProposalResponsePayload{Extension: ccAction}),
+					Endorsements:            []*pb.Endorsement{{Endorser: creator}},
+				},
+			}),
+		}},
+	})
+
+	env := marshal(t, &common.Envelope{
+		Payload: marshal(t, &common.Payload{
+			Header: &common.Header{
+				ChannelHeader:   marshal(t, &common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), TxId: txID, ChannelId: "mychannel"}),
+				SignatureHeader: marshal(t, &common.SignatureHeader{Creator: creator}),
+			},
+			Data: tx,
+		}),
+	})
+
+	return &common.Block{
+		Header: &common.BlockHeader{Number: blockNum, PreviousHash: []byte{1, 2}, DataHash: []byte{3, 4}},
+		Data:   &common.BlockData{Data: [][]byte{env}},
+		Metadata: &common.BlockMetadata{
+			Metadata: [][]byte{
+				marshal(t, &common.Metadata{}),
+				marshal(t, &common.Metadata{Value: marshal(t, &common.LastConfig{Index: 2})}),
+				{byte(code)},
+			},
+		},
+	}
+}
+
+func marshal(t *testing.T, msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,310 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/pkg/errors"
+)
+
+const blockListenerPollInterval = 250 * time.Millisecond
+
+// BlockListener opens a block (or filtered block) delivery stream to each peer on a channel
+// and records the block numbers delivered by each peer. Peers are identified by their peer ID
+// (see PeerConfig) or, if the peer has no ID, by their URL.
+type BlockListener struct {
+	channelID string
+	filtered  bool
+	mutex     sync.RWMutex
+	delivered map[string]map[uint64]bool
+	heights   map[string]uint64
+	clients   []*deliverclient.Client
+}
+
+// NewBlockListener connects to the deliver service of each peer on the given channel. If filtered is true
+// then filtered blocks are requested, otherwise full blocks are requested. All blocks are delivered
+// starting from the genesis block.
+func NewBlockListener(bddCtx *BDDContext, channelID string, filtered bool) (*BlockListener, error) {
+	peers := bddCtx.PeersByChannel(channelID)
+	if len(peers) == 0 {
+		return nil, errors.Errorf("no peers found for channel [%s]", channelID)
+	}
+
+	l := newBlockListener(channelID, filtered)
+
+	for _, pconfig := range peers {
+		if err := l.connect(bddCtx, pconfig); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func newBlockListener(channelID string, filtered bool) *BlockListener {
+	return &BlockListener{
+		channelID: channelID,
+		filtered:  filtered,
+		delivered: make(map[string]map[uint64]bool),
+		heights:   make(map[string]uint64),
+	}
+}
+
+// blockListenerPeerID returns the ID by which the given peer is identified by the block listener. The peer ID
+// is derived from the ssl-target-name-override of the peer so the URL is used if the override isn't configured.
+func blockListenerPeerID(pconfig *PeerConfig) string {
+	if pconfig.PeerID != "" {
+		return pconfig.PeerID
+	}
+	return pconfig.Config.URL
+}
+
+func (l *BlockListener) connect(bddCtx *BDDContext, pconfig *PeerConfig) error {
+	chCtx, err := bddCtx.Sdk().ChannelContext(l.channelID, fabsdk.WithUser(userName(USER)), fabsdk.WithOrg(pconfig.OrgID))()
+	if err != nil {
+		return errors.WithMessagef(err, "error creating channel context for org [%s]", pconfig.OrgID)
+	}
+
+	chConfig, err := chCtx.ChannelService().ChannelConfig()
+	if err != nil {
+		return errors.WithMessagef(err, "error getting channel config for channel [%s]", l.channelID)
+	}
+
+	peer, err := chCtx.InfraProvider().CreatePeerFromConfig(&fabApi.NetworkPeer{PeerConfig: pconfig.Config, MSPID: pconfig.MspID})
+	if err != nil {
+		return errors.WithMessage(err, "NewPeer failed")
+	}
+
+	opts := []options.Opt{deliverclient.WithSeekType(seek.Oldest)}
+	if !l.filtered {
+		opts = append(opts, client.WithBlockEvents())
+	}
+
+	deliverClient, err := deliverclient.New(chCtx, chConfig, &staticDiscovery{peers: []fabApi.Peer{peer}}, opts...)
+	if err != nil {
+		return errors.WithMessagef(err, "error connecting to deliver service of peer [%s]", pconfig.Config.URL)
+	}
+
+	peerID := blockListenerPeerID(pconfig)
+
+	l.mutex.Lock()
+	l.clients = append(l.clients, deliverClient)
+	l.mutex.Unlock()
+
+	l.addPeer(peerID)
+
+	if l.filtered {
+		_, eventch, err := deliverClient.RegisterFilteredBlockEvent()
+		if err != nil {
+			return errors.WithMessagef(err, "error registering for filtered block events on peer [%s]", peerID)
+		}
+		go func() {
+			for e := range eventch {
+				l.blockDelivered(peerID, e.FilteredBlock.Number)
+			}
+		}()
+	} else {
+		_, eventch, err := deliverClient.RegisterBlockEvent()
+		if err != nil {
+			return errors.WithMessagef(err, "error registering for block events on peer [%s]", peerID)
+		}
+		go func() {
+			for e := range eventch {
+				l.blockDelivered(peerID, e.Block.Header.Number)
+			}
+		}()
+	}
+
+	logger.Infof("Started block listener for peer [%s] on channel [%s] - Filtered: %t", peerID, l.channelID, l.filtered)
+	return nil
+}
+
+func (l *BlockListener) addPeer(peerID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.delivered[peerID] = make(map[uint64]bool)
+}
+
+func (l *BlockListener) blockDelivered(peerID string, blockNum uint64) {
+	logger.Debugf("Peer [%s] delivered block %d on channel [%s]", peerID, blockNum, l.channelID)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.delivered[peerID][blockNum] = true
+	if blockNum+1 > l.heights[peerID] {
+		l.heights[peerID] = blockNum + 1
+	}
+}
+
+// ChannelID returns the channel ID
+func (l *BlockListener) ChannelID() string {
+	return l.channelID
+}
+
+// Delivered returns true if the given peer has delivered the given block
+func (l *BlockListener) Delivered(peerID string, blockNum uint64) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.delivered[peerID][blockNum]
+}
+
+// Heights returns the block height (the highest delivered block number + 1) of each peer
+func (l *BlockListener) Heights() map[string]uint64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	heights := make(map[string]uint64)
+	for peerID := range l.delivered {
+		heights[peerID] = l.heights[peerID]
+	}
+	return heights
+}
+
+// WaitForHeight waits until all peers have reached at least the given block height. An error is
+// returned listing the peers that did not reach the height within the given timeout.
+func (l *BlockListener) WaitForHeight(minHeight uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		lagging := laggingPeers(l.Heights(), minHeight)
+		if len(lagging) == 0 {
+			logger.Infof("All peers on channel [%s] reached block height %d", l.channelID, minHeight)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("peers did not reach block height %d on channel [%s] within %s: %s", minHeight, l.channelID, timeout, strings.Join(lagging, ", "))
+		}
+		time.Sleep(blockListenerPollInterval)
+	}
+}
+
+// WaitForSameHeight waits until all peers have delivered blocks and report the same block height
+func (l *BlockListener) WaitForSameHeight(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		heights := l.Heights()
+
+		var maxHeight uint64
+		for _, height := range heights {
+			if height > maxHeight {
+				maxHeight = height
+			}
+		}
+
+		lagging := laggingPeers(heights, maxHeight)
+		if maxHeight > 0 && len(lagging) == 0 {
+			logger.Infof("All peers on channel [%s] are at block height %d", l.channelID, maxHeight)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("peers did not reach block height %d on channel [%s] within %s: %s", maxHeight, l.channelID, timeout, strings.Join(lagging, ", "))
+		}
+		time.Sleep(blockListenerPollInterval)
+	}
+}
+
+// Close closes the delivery streams to all peers
+func (l *BlockListener) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, c := range l.clients {
+		c.Close()
+	}
+	l.clients = nil
+}
+
+func laggingPeers(heights map[string]uint64, minHeight uint64) []string {
+	var lagging []string
+	for peerID, height := range heights {
+		if height < minHeight {
+			lagging = append(lagging, fmt.Sprintf("%s (height %d)", peerID, height))
+		}
+	}
+	sort.Strings(lagging)
+	return lagging
+}
+
+// staticDiscovery is a discovery service that always returns the given peers
+type staticDiscovery struct {
+	peers []fabApi.Peer
+}
+
+func (s *staticDiscovery) GetPeers() ([]fabApi.Peer, error) {
+	return s.peers, nil
+}
+
+// StartBlockListener starts a block listener on the given channel. Any existing listener on the
+// channel is closed.
+func (d *CommonSteps) StartBlockListener(channelID string, filtered bool) (*BlockListener, error) {
+	l, err := NewBlockListener(d.BDDContext, channelID, filtered)
+	if err != nil {
+		return nil, err
+	}
+	d.BDDContext.SetBlockListener(l)
+	return l, nil
+}
+
+func (d *CommonSteps) startBlockListener(channelID string) error {
+	_, err := d.StartBlockListener(channelID, false)
+	return err
+}
+
+func (d *CommonSteps) startFilteredBlockListener(channelID string) error {
+	_, err := d.StartBlockListener(channelID, true)
+	return err
+}
+
+// blockListener returns the block listener for the given channel, starting a filtered
+// block listener if one doesn't exist
+func (d *CommonSteps) blockListener(channelID string) (*BlockListener, error) {
+	if l := d.BDDContext.BlockListener(channelID); l != nil {
+		return l, nil
+	}
+	return d.StartBlockListener(channelID, true)
+}
+
+func (d *CommonSteps) allPeersReachBlockHeight(channelID string, minHeight, seconds int) error {
+	l, err := d.blockListener(channelID)
+	if err != nil {
+		return err
+	}
+	return l.WaitForHeight(uint64(minHeight), time.Duration(seconds)*time.Second)
+}
+
+func (d *CommonSteps) allPeersReachSameBlockHeight(channelID string, seconds int) error {
+	l, err := d.blockListener(channelID)
+	if err != nil {
+		return err
+	}
+	return l.WaitForSameHeight(time.Duration(seconds) * time.Second)
+}
+
+func (d *CommonSteps) peerDeliveredBlock(peerID string, blockNum int, channelID string) error {
+	l := d.BDDContext.BlockListener(channelID)
+	if l == nil {
+		return errors.Errorf("no block listener started on channel [%s]", channelID)
+	}
+	if !l.Delivered(peerID, uint64(blockNum)) {
+		return errors.Errorf("peer [%s] has not delivered block %d on channel [%s]", peerID, blockNum, channelID)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+	"time"
+
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockListenerPeerID(t *testing.T) {
+	assert.Equal(t, "peer0.org1.example.com", blockListenerPeerID(&PeerConfig{PeerID: "peer0.org1.example.com", Config: fabApi.PeerConfig{URL: "localhost:7051"}}))
+	assert.Equal(t, "localhost:7051", blockListenerPeerID(&PeerConfig{Config: fabApi.PeerConfig{URL: "localhost:7051"}}))
+}
+
+func TestLaggingPeers(t *testing.T) {
+	tests := []struct {
+		name      string
+		heights   map[string]uint64
+		minHeight uint64
+		expected  []string
+	}{
+		{name: "None", heights: map[string]uint64{"peer0": 5, "peer1": 6}, minHeight: 5},
+		{name: "Some", heights: map[string]uint64{"peer0": 5, "peer1": 3, "peer2": 0}, minHeight: 4, expected: []string{"peer1 (height 3)", "peer2 (height 0)"}},
+		{name: "No peers", minHeight: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, laggingPeers(tc.heights, tc.minHeight))
+		})
+	}
+}
+
+func TestBlockListenerWaitForHeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocks    map[string][]uint64
+		minHeight uint64
+		expectErr bool
+	}{
+		{name: "All peers reached height", blocks: map[string][]uint64{"peer0": {0, 1, 2}, "peer1": {0, 1, 2, 3}}, minHeight: 3},
+		{name: "Peer lagging", blocks: map[string][]uint64{"peer0": {0, 1, 2}, "peer1": {0, 1}}, minHeight: 3, expectErr: true},
+		{name: "Peer with no blocks", blocks: map[string][]uint64{"peer0": {0, 1, 2}, "peer1": nil}, minHeight: 1, expectErr: true},
+		{name: "Out of order delivery", blocks: map[string][]uint64{"peer0": {2, 0, 1}}, minHeight: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newTestBlockListener(tc.blocks)
+
+			err := l.WaitForHeight(tc.minHeight, 0)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("Reached within timeout", func(t *testing.T) {
+		l := newTestBlockListener(map[string][]uint64{"peer0": {0, 1}, "peer1": {0}})
+
+		go func() {
+			time.Sleep(2 * blockListenerPollInterval)
+			l.blockDelivered("peer1", 1)
+		}()
+
+		require.NoError(t, l.WaitForHeight(2, 5*time.Second))
+		assert.True(t, l.Delivered("peer1", 1))
+		assert.False(t, l.Delivered("peer1", 2))
+	})
+}
+
+func TestBlockListenerWaitForSameHeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocks    map[string][]uint64
+		expectErr bool
+	}{
+		{name: "Same height", blocks: map[string][]uint64{"peer0": {0, 1, 2}, "peer1": {0, 1, 2}}},
+		{name: "Different heights", blocks: map[string][]uint64{"peer0": {0, 1, 2}, "peer1": {0, 1}}, expectErr: true},
+		{name: "Peer with no blocks", blocks: map[string][]uint64{"peer0": {0}, "peer1": nil}, expectErr: true},
+		{name: "No blocks", blocks: map[string][]uint64{"peer0": nil, "peer1": nil}, expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newTestBlockListener(tc.blocks)
+
+			err := l.WaitForSameHeight(0)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func newTestBlockListener(blocks map[string][]uint64) *BlockListener {
+	l := newBlockListener("mychannel", true)
+	for peerID, blockNums := range blocks {
+		l.addPeer(peerID)
+		for _, blockNum := range blockNums {
+			l.blockDelivered(peerID, blockNum)
+		}
+	}
+	return l
+}
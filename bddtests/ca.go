@@ -0,0 +1,251 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"strings"
+
+	mspclient "github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	"github.com/pkg/errors"
+)
+
+const ecertAttrSuffix = ":ecert"
+
+// caClient returns an MSP client for the (first) CA of the given org in the SDK config
+func (d *CommonSteps) caClient(orgID string) (*mspclient.Client, error) {
+	sdk := d.BDDContext.Sdk()
+	if sdk == nil {
+		return nil, errors.New("SDK is not initialized")
+	}
+
+	client, err := mspclient.New(sdk.Context(), mspclient.WithOrg(orgID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error creating CA client for org [%s]", orgID)
+	}
+	return client, nil
+}
+
+// RegisterWithCA registers the given user with the CA of the given org and returns the enrollment secret.
+// The secret is also retained so that the user may subsequently be enrolled.
+func (d *CommonSteps) RegisterWithCA(orgID, name, idType, affiliation string, attrs []mspclient.Attribute) (string, error) {
+	client, err := d.caClient(orgID)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := client.Register(&mspclient.RegistrationRequest{
+		Name:        name,
+		Type:        idType,
+		Affiliation: affiliation,
+		Attributes:  attrs,
+	})
+	if err != nil {
+		return "", errors.WithMessagef(err, "error registering user [%s] with the CA of org [%s]", name, orgID)
+	}
+
+	d.BDDContext.SetEnrollmentSecret(orgID, name, secret)
+	return secret, nil
+}
+
+// EnrollWithCA enrolls the given (registered) user with the CA of the given org and registers the enrolled
+// identity with BDDContext under the user's name. If attribute names are provided then the enrollment
+// certificate is requested to include those attributes.
+func (d *CommonSteps) EnrollWithCA(orgID, name string, attrNames ...string) error {
+	secret, ok := d.BDDContext.EnrollmentSecret(orgID, name)
+	if !ok {
+		return errors.Errorf("user [%s] has not been registered with the CA of org [%s]", name, orgID)
+	}
+
+	client, err := d.caClient(orgID)
+	if err != nil {
+		return err
+	}
+
+	opts := []mspclient.EnrollmentOption{mspclient.WithSecret(secret)}
+	if len(attrNames) > 0 {
+		var attrReqs []*mspclient.AttributeRequest
+		for _, attrName := range attrNames {
+			attrReqs = append(attrReqs, &mspclient.AttributeRequest{Name: attrName})
+		}
+		opts = append(opts, mspclient.WithAttributeRequests(attrReqs))
+	}
+
+	if err := client.Enroll(name, opts...); err != nil {
+		return errors.WithMessagef(err, "error enrolling user [%s] with the CA of org [%s]", name, orgID)
+	}
+
+	return d.registerEnrolledIdentity(client, orgID, name)
+}
+
+// ReenrollWithCA re-enrolls the given user with the CA of the given org and registers the new identity with BDDContext
+func (d *CommonSteps) ReenrollWithCA(orgID, name string) error {
+	client, err := d.caClient(orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Reenroll(name); err != nil {
+		return errors.WithMessagef(err, "error re-enrolling user [%s] with the CA of org [%s]", name, orgID)
+	}
+
+	return d.registerEnrolledIdentity(client, orgID, name)
+}
+
+// RevokeWithCA revokes the certificates of the given user with the CA of the given org. Note that peers only reject
+// the revoked identity once the CA's CRL has been added to the MSP in the channel config.
+func (d *CommonSteps) RevokeWithCA(orgID, name, reason string) error {
+	client, err := d.caClient(orgID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Revoke(&mspclient.RevocationRequest{Name: name, Reason: reason})
+	if err != nil {
+		return errors.WithMessagef(err, "error revoking user [%s] with the CA of org [%s]", name, orgID)
+	}
+
+	logger.Infof("Revoked %d certificate(s) of user [%s] with the CA of org [%s]", len(resp.RevokedCerts), name, orgID)
+	return nil
+}
+
+func (d *CommonSteps) registerEnrolledIdentity(client *mspclient.Client, orgID, name string) error {
+	identity, err := client.GetSigningIdentity(name)
+	if err != nil {
+		return errors.WithMessagef(err, "error getting signing identity of user [%s] of org [%s]", name, orgID)
+	}
+
+	d.BDDContext.RegisterIdentity(orgID, name, identity)
+	return nil
+}
+
+func (d *CommonSteps) registerUserWithCA(name, orgID, idType, affiliation string) error {
+	return d.registerUserWithCAAndAttributes(name, orgID, idType, affiliation, "")
+}
+
+func (d *CommonSteps) registerUserWithCAAndAttributes(name, orgID, idType, affiliation, attributes string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	attributes, err = d.BDDContext.Vars().Resolve(attributes)
+	if err != nil {
+		return err
+	}
+
+	attrs, err := parseCAAttributes(attributes)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Registering user [%s] of type [%s] with affiliation [%s] and attributes %v with the CA of org [%s]", name, idType, affiliation, attrs, orgID)
+
+	_, err = d.RegisterWithCA(orgID, name, idType, affiliation, attrs)
+	return err
+}
+
+func (d *CommonSteps) enrollUserWithCA(name, orgID string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Enrolling user [%s] with the CA of org [%s]", name, orgID)
+	return d.EnrollWithCA(orgID, name)
+}
+
+func (d *CommonSteps) enrollUserWithCAAndAttributes(name, orgID, attrNames string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	attrNames, err = d.BDDContext.Vars().Resolve(attrNames)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Enrolling user [%s] with the CA of org [%s] requesting attributes [%s]", name, orgID, attrNames)
+	return d.EnrollWithCA(orgID, name, parseCAAttributeNames(attrNames)...)
+}
+
+// enrollUserWithCAAndSecret enrolls a user which was registered with the CA of the given org outside of the
+// test suite (for example, by the CA's bootstrap configuration) using the given enrollment secret
+func (d *CommonSteps) enrollUserWithCAAndSecret(name, orgID, secret string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	secret, err = d.BDDContext.Vars().Resolve(secret)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Enrolling user [%s] with the CA of org [%s] using the given secret", name, orgID)
+	d.BDDContext.SetEnrollmentSecret(orgID, name, secret)
+	return d.EnrollWithCA(orgID, name)
+}
+
+func (d *CommonSteps) reenrollUserWithCA(name, orgID string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Re-enrolling user [%s] with the CA of org [%s]", name, orgID)
+	return d.ReenrollWithCA(orgID, name)
+}
+
+func (d *CommonSteps) revokeUserWithCA(name, orgID string) error {
+	name, err := d.BDDContext.Vars().Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Revoking user [%s] with the CA of org [%s]", name, orgID)
+	return d.RevokeWithCA(orgID, name, "")
+}
+
+// parseCAAttributeNames parses the given comma-separated attribute names, e.g. "role, dept"
+func parseCAAttributeNames(attrNames string) []string {
+	var names []string
+	for _, name := range SplitArgs(attrNames) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseCAAttributes parses the given comma-separated attributes, each of the form name=value. If the value has
+// the suffix ":ecert" then the attribute is included in the enrollment certificate by default,
+// e.g. "role=auditor:ecert,dept=finance"
+func parseCAAttributes(attributes string) ([]mspclient.Attribute, error) {
+	if strings.TrimSpace(attributes) == "" {
+		return nil, nil
+	}
+
+	var attrs []mspclient.Attribute
+	for _, attr := range SplitArgs(attributes) {
+		i := strings.Index(attr, "=")
+		if i <= 0 {
+			return nil, errors.Errorf("invalid attribute [%s] - expecting name=value", attr)
+		}
+
+		name := strings.TrimSpace(attr[0:i])
+		value := attr[i+1:]
+		ecert := strings.HasSuffix(value, ecertAttrSuffix)
+		if ecert {
+			value = strings.TrimSuffix(value, ecertAttrSuffix)
+		}
+
+		attrs = append(attrs, mspclient.Attribute{Name: name, Value: value, ECert: ecert})
+	}
+
+	return attrs, nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	mspclient "github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCAAttributes(t *testing.T) {
+	attrs, err := parseCAAttributes("")
+	require.NoError(t, err)
+	assert.Empty(t, attrs)
+
+	attrs, err = parseCAAttributes("role=auditor:ecert,dept=finance,url=http://x?a=b")
+	require.NoError(t, err)
+	assert.Equal(t, []mspclient.Attribute{
+		{Name: "role", Value: "auditor", ECert: true},
+		{Name: "dept", Value: "finance"},
+		{Name: "url", Value: "http://x?a=b"},
+	}, attrs)
+
+	_, err = parseCAAttributes("role")
+	assert.Error(t, err)
+
+	_, err = parseCAAttributes("=auditor")
+	assert.Error(t, err)
+}
+
+func TestParseCAAttributeNames(t *testing.T) {
+	assert.Empty(t, parseCAAttributeNames(""))
+	assert.Equal(t, []string{"role"}, parseCAAttributeNames("role"))
+	assert.Equal(t, []string{"role", "dept", "hf.EnrollmentID"}, parseCAAttributeNames("role, dept ,hf.EnrollmentID,"))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// endorsementPolicyFieldNum is the field number of endorsement_policy in the StaticCollectionConfig message
+// (introduced in Fabric 2.0)
+const endorsementPolicyFieldNum = 8
+
+// CollectionConfigJSON is a single collection in a Fabric collections_config.json file
+type CollectionConfigJSON struct {
+	Name              string                 `json:"name"`
+	Policy            string                 `json:"policy"`
+	RequiredPeerCount int32                  `json:"requiredPeerCount"`
+	MaxPeerCount      int32                  `json:"maxPeerCount"`
+	BlockToLive       uint64                 `json:"blockToLive"`
+	MemberOnlyRead    bool                   `json:"memberOnlyRead"`
+	MemberOnlyWrite   bool                   `json:"memberOnlyWrite"`
+	EndorsementPolicy *EndorsementPolicyJSON `json:"endorsementPolicy,omitempty"`
+}
+
+// EndorsementPolicyJSON is the collection-level endorsement policy in a collections_config.json file
+type EndorsementPolicyJSON struct {
+	SignaturePolicy     string `json:"signaturePolicy,omitempty"`
+	ChannelConfigPolicy string `json:"channelConfigPolicy,omitempty"`
+}
+
+// ParseCollectionConfigs parses the contents of a Fabric collections_config.json file
+func ParseCollectionConfigs(data []byte) ([]*CollectionConfigJSON, error) {
+	var configs []*CollectionConfigJSON
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, errors.Wrap(err, "invalid collection configs")
+	}
+
+	for _, c := range configs {
+		if c.Name == "" {
+			return nil, errors.New("collection name is required")
+		}
+		if c.MaxPeerCount < c.RequiredPeerCount {
+			return nil, errors.Errorf("maxPeerCount (%d) must not be less than requiredPeerCount (%d) for collection [%s]", c.MaxPeerCount, c.RequiredPeerCount, c.Name)
+		}
+		if p := c.EndorsementPolicy; p != nil && (p.SignaturePolicy == "") == (p.ChannelConfigPolicy == "") {
+			return nil, errors.Errorf("endorsementPolicy for collection [%s] must have either a signaturePolicy or a channelConfigPolicy", c.Name)
+		}
+	}
+
+	return configs, nil
+}
+
+// LoadCollectionConfigs loads the collection configs from the given collections_config.json file. Variables within
+// the file are resolved. Each collection config is defined in the collection registry with the collection name
+// as the ID. The names of the loaded collections are returned.
+func (d *CommonSteps) LoadCollectionConfigs(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading collection configs from [%s]", path)
+	}
+
+	resolved, err := d.BDDContext.Vars().Resolve(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := ParseCollectionConfigs([]byte(resolved))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error loading collection configs from [%s]", path)
+	}
+
+	var names []string
+	for _, c := range configs {
+		d.defineCollectionConfigFromJSON(c)
+		names = append(names, c.Name)
+	}
+
+	return names, nil
+}
+
+func (d *CommonSteps) defineCollectionConfigFromJSON(c *CollectionConfigJSON) {
+	d.BDDContext.DefineCollectionConfig(c.Name,
+		func(channelID string) (*common.CollectionConfig, error) {
+			sigPolicy, err := d.newChaincodePolicy(c.Policy, channelID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error creating collection policy for collection [%s]", c.Name)
+			}
+
+			endorsementPolicy, err := d.newCollectionEndorsementPolicy(c.EndorsementPolicy, channelID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error creating endorsement policy for collection [%s]", c.Name)
+			}
+
+			return newStaticCollectionConfig(c, sigPolicy, endorsementPolicy)
+		},
+	)
+}
+
+func (d *CommonSteps) newCollectionEndorsementPolicy(p *EndorsementPolicyJSON, channelID string) (*pb.ApplicationPolicy, error) {
+	switch {
+	case p == nil:
+		return nil, nil
+	case p.ChannelConfigPolicy != "":
+		return &pb.ApplicationPolicy{
+			Type: &pb.ApplicationPolicy_ChannelConfigPolicyReference{ChannelConfigPolicyReference: p.ChannelConfigPolicy},
+		}, nil
+	default:
+		sigPolicy, err := d.newChaincodePolicy(p.SignaturePolicy, channelID)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ApplicationPolicy{
+			Type: &pb.ApplicationPolicy_SignaturePolicy{SignaturePolicy: sigPolicy},
+		}, nil
+	}
+}
+
+func (d *CommonSteps) loadCollectionConfigs(path string) error {
+	path, err := d.BDDContext.Vars().Resolve(path)
+	if err != nil {
+		return err
+	}
+
+	names, err := d.LoadCollectionConfigs(path)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Loaded collection configs %s from [%s]", names, path)
+	return nil
+}
+
+func newStaticCollectionConfig(c *CollectionConfigJSON, policy *common.SignaturePolicyEnvelope, endorsementPolicy *pb.ApplicationPolicy) (*common.CollectionConfig, error) {
+	config := newPrivateCollectionConfig(c.Name, c.RequiredPeerCount, c.MaxPeerCount, c.BlockToLive, policy)
+	staticConfig := config.GetStaticCollectionConfig()
+	staticConfig.MemberOnlyRead = c.MemberOnlyRead
+	staticConfig.MemberOnlyWrite = c.MemberOnlyWrite
+
+	if endorsementPolicy != nil {
+		if err := setCollectionEndorsementPolicy(staticConfig, endorsementPolicy); err != nil {
+			return nil, errors.WithMessagef(err, "error setting endorsement policy for collection [%s]", c.Name)
+		}
+	}
+
+	return config, nil
+}
+
+// setCollectionEndorsementPolicy sets the endorsement policy of the given collection config. The version of
+// fabric-protos-go used by this module predates the endorsement_policy field of StaticCollectionConfig so the
+// field is encoded into the unrecognized fields of the message, which are included when the message is marshalled.
+func setCollectionEndorsementPolicy(config *common.StaticCollectionConfig, policy *pb.ApplicationPolicy) error {
+	policyBytes, err := proto.Marshal(policy)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling endorsement policy")
+	}
+
+	buf := proto.NewBuffer(nil)
+	if err := buf.EncodeVarint(uint64(endorsementPolicyFieldNum<<3 | proto.WireBytes)); err != nil {
+		return errors.Wrap(err, "error encoding endorsement policy")
+	}
+	if err := buf.EncodeRawBytes(policyBytes); err != nil {
+		return errors.Wrap(err, "error encoding endorsement policy")
+	}
+
+	config.XXX_unrecognized = append(config.XXX_unrecognized, buf.Bytes()...)
+	return nil
+}
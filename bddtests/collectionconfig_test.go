@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCollectionConfigs(t *testing.T) {
+	configs, err := ParseCollectionConfigs([]byte(`[
+		{
+			"name": "coll1",
+			"policy": "OR('Org1MSP.member','Org2MSP.member')",
+			"requiredPeerCount": 1,
+			"maxPeerCount": 2,
+			"blockToLive": 3,
+			"memberOnlyRead": true,
+			"memberOnlyWrite": true
+		},
+		{
+			"name": "coll2",
+			"policy": "OR('Org1MSP.member')",
+			"requiredPeerCount": 0,
+			"maxPeerCount": 1,
+			"endorsementPolicy": {
+				"signaturePolicy": "OR('Org1MSP.member')"
+			}
+		},
+		{
+			"name": "coll3",
+			"policy": "OR('Org1MSP.member')",
+			"requiredPeerCount": 0,
+			"maxPeerCount": 1,
+			"endorsementPolicy": {
+				"channelConfigPolicy": "/Channel/Application/Endorsement"
+			}
+		}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+
+	policy, err := newPolicy(configs[0].Policy)
+	require.NoError(t, err)
+
+	collConfig, err := newStaticCollectionConfig(configs[0], policy, nil)
+	require.NoError(t, err)
+
+	config := collConfig.GetStaticCollectionConfig()
+	assert.Equal(t, "coll1", config.Name)
+	assert.Equal(t, int32(1), config.RequiredPeerCount)
+	assert.Equal(t, int32(2), config.MaximumPeerCount)
+	assert.Equal(t, uint64(3), config.BlockToLive)
+	assert.True(t, config.MemberOnlyRead)
+	assert.True(t, config.MemberOnlyWrite)
+	assert.Equal(t, policy, config.GetMemberOrgsPolicy().GetSignaturePolicy())
+	assert.Empty(t, config.XXX_unrecognized)
+
+	assert.False(t, configs[1].MemberOnlyRead)
+	assert.False(t, configs[1].MemberOnlyWrite)
+
+	t.Run("Signature endorsement policy", func(t *testing.T) {
+		epPolicy, err := newPolicy(configs[1].EndorsementPolicy.SignaturePolicy)
+		require.NoError(t, err)
+
+		endorsementPolicy := marshalAndGetEndorsementPolicy(t, configs[1], policy, &pb.ApplicationPolicy{
+			Type: &pb.ApplicationPolicy_SignaturePolicy{SignaturePolicy: epPolicy},
+		})
+		require.NotNil(t, endorsementPolicy)
+		assert.True(t, proto.Equal(epPolicy, endorsementPolicy.GetSignaturePolicy()))
+	})
+
+	t.Run("Channel config endorsement policy", func(t *testing.T) {
+		endorsementPolicy := marshalAndGetEndorsementPolicy(t, configs[2], policy, &pb.ApplicationPolicy{
+			Type: &pb.ApplicationPolicy_ChannelConfigPolicyReference{ChannelConfigPolicyReference: configs[2].EndorsementPolicy.ChannelConfigPolicy},
+		})
+		require.NotNil(t, endorsementPolicy)
+		assert.Equal(t, "/Channel/Application/Endorsement", endorsementPolicy.GetChannelConfigPolicyReference())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseCollectionConfigs([]byte(`{"name": "coll1"}`))
+		assert.Error(t, err)
+
+		_, err = ParseCollectionConfigs([]byte(`[{"policy": "OR('Org1MSP.member')"}]`))
+		assert.Error(t, err)
+
+		_, err = ParseCollectionConfigs([]byte(`[{"name": "coll1", "requiredPeerCount": 2, "maxPeerCount": 1}]`))
+		assert.Error(t, err)
+
+		_, err = ParseCollectionConfigs([]byte(`[{"name": "coll1", "endorsementPolicy": {}}]`))
+		assert.Error(t, err)
+
+		_, err = ParseCollectionConfigs([]byte(`[{"name": "coll1", "endorsementPolicy": {"signaturePolicy": "OR('Org1MSP.member')", "channelConfigPolicy": "/Channel/Application/Endorsement"}}]`))
+		assert.Error(t, err)
+	})
+}
+
+// marshalAndGetEndorsementPolicy creates the collection config with the given endorsement policy and returns the
+// endorsement policy of the collection config after a marshal/unmarshal round trip
+func marshalAndGetEndorsementPolicy(t *testing.T, c *CollectionConfigJSON, policy *common.SignaturePolicyEnvelope, endorsementPolicy *pb.ApplicationPolicy) *pb.ApplicationPolicy {
+	collConfig, err := newStaticCollectionConfig(c, policy, endorsementPolicy)
+	require.NoError(t, err)
+
+	configBytes, err := proto.Marshal(&common.CollectionConfigPackage{Config: []*common.CollectionConfig{collConfig}})
+	require.NoError(t, err)
+
+	configPkg := &common.CollectionConfigPackage{}
+	require.NoError(t, proto.Unmarshal(configBytes, configPkg))
+	require.Len(t, configPkg.Config, 1)
+
+	config := configPkg.Config[0].GetStaticCollectionConfig()
+	require.NotNil(t, config)
+	assert.Equal(t, c.Name, config.Name)
+
+	p, err := collectionEndorsementPolicy(config)
+	require.NoError(t, err)
+	return p
+}
+
+// collectionEndorsementPolicy returns the endorsement policy of the given collection config or nil if the
+// collection doesn't have an endorsement policy (see setCollectionEndorsementPolicy)
+func collectionEndorsementPolicy(config *common.StaticCollectionConfig) (*pb.ApplicationPolicy, error) {
+	b := config.XXX_unrecognized
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	key, n := proto.DecodeVarint(b)
+	if n == 0 {
+		return nil, errors.New("error decoding collection config")
+	}
+
+	if key>>3 != endorsementPolicyFieldNum || key&7 != proto.WireBytes {
+		return nil, errors.Errorf("unexpected field %d in collection config", key>>3)
+	}
+
+	policyBytes, err := proto.NewBuffer(b[n:]).DecodeRawBytes(false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding endorsement policy")
+	}
+
+	policy := &pb.ApplicationPolicy{}
+	if err := proto.Unmarshal(policyBytes, policy); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling endorsement policy")
+	}
+	return policy, nil
+}
@@ -7,21 +7,26 @@ SPDX-License-Identifier: Apache-2.0
 package bddtests
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
 	"github.com/hyperledger/fabric-protos-go/common"
 	fabricCommon "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
@@ -53,9 +58,6 @@ func (p Peers) Shuffle() Peers {
 
 var logger = logging.NewLogger("test-logger")
 
-var queryValue string
-var vars = make(map[string]string)
-
 type queryInfoResponse struct {
 	Height            string
 	CurrentBlockHash  string
@@ -86,62 +88,229 @@ func (d *CommonSteps) getDeployPath(ccType string) string {
 }
 
 func (d *CommonSteps) displayBlockFromChannel(blockNum int, channelID string) error {
-	block, err := d.getBlocks(channelID, blockNum, 1)
+	blocks, err := d.getBlocks(channelID, blockNum, 1)
 	if err != nil {
 		return err
 	}
-	logger.Infof("%s\n", block)
-	return nil
+	return displayBlocks(blocks)
+}
+
+// QueryBlocks returns up to numBlocks decoded blocks from the given channel, starting at blockNum
+// and traversing backwards towards the genesis block
+func (d *CommonSteps) QueryBlocks(channelID string, blockNum, numBlocks int) ([]*Block, error) {
+	return d.getBlocks(channelID, blockNum, numBlocks)
+}
+
+// QueryChannelInfo returns the blockchain info for the given channel from a peer in one of the channel's orgs
+func (d *CommonSteps) QueryChannelInfo(channelID string) (*fabApi.BlockchainInfoResponse, error) {
+	ledgerClient, target, err := d.ledgerClient(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ledgerClient.QueryInfo(ledger.WithTargets(target))
+}
+
+func (d *CommonSteps) getBlocks(channelID string, blockNum, numBlocks int) ([]*Block, error) {
+	ledgerClient, target, err := d.ledgerClient(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*Block
+	for i := 0; i < numBlocks && blockNum-i >= 0; i++ {
+		block, err := ledgerClient.QueryBlock(uint64(blockNum-i), ledger.WithTargets(target))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error querying block %d on channel [%s]", blockNum-i, channelID)
+		}
+
+		decodedBlock, err := DecodeBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, decodedBlock)
+	}
+
+	return blocks, nil
 }
 
-func (d *CommonSteps) getBlocks(channelID string, blockNum, numBlocks int) (string, error) {
+func (d *CommonSteps) ledgerClient(channelID string) (*ledger.Client, fabApi.Peer, error) {
 	orgID, err := d.BDDContext.OrgIDForChannel(channelID)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+
+	peers := d.OrgPeers(orgID, channelID)
+	if len(peers) == 0 {
+		return nil, nil, errors.Errorf("no peers found for org [%s] on channel [%s]", orgID, channelID)
 	}
 
-	strBlockNum := fmt.Sprintf("%d", blockNum)
-	strNumBlocks := fmt.Sprintf("%d", numBlocks)
-	return NewFabCLI().Exec("query", "block", "--config", d.BDDContext.clientConfigFilePath+d.BDDContext.clientConfigFileName, "--cid", channelID, "--orgid", orgID, "--num", strBlockNum, "--traverse", strNumBlocks)
+	return d.peerLedgerClient(channelID, peers.Shuffle()[0])
+}
+
+func displayBlocks(blocks []*Block) error {
+	for _, block := range blocks {
+		blockBytes, err := json.MarshalIndent(block, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "error marshalling block %d", block.Number)
+		}
+		logger.Infof("%s\n", blockBytes)
+	}
+	return nil
 }
 
 func (d *CommonSteps) displayBlocksFromChannel(numBlocks int, channelID string) error {
 	height, err := d.getChannelBlockHeight(channelID)
 	if err != nil {
-		return fmt.Errorf("error getting channel height: %s", err)
+		return errors.WithMessage(err, "error getting channel height")
 	}
 
-	block, err := d.getBlocks(channelID, height-1, numBlocks)
+	blocks, err := d.getBlocks(channelID, height-1, numBlocks)
 	if err != nil {
 		return err
 	}
 
-	logger.Infof("%s\n", block)
-
-	return nil
+	return displayBlocks(blocks)
 }
 
 func (d *CommonSteps) getChannelBlockHeight(channelID string) (int, error) {
-	orgID, err := d.BDDContext.OrgIDForChannel(channelID)
+	resp, err := d.QueryChannelInfo(channelID)
 	if err != nil {
 		return 0, err
 	}
 
-	resp, err := NewFabCLI().GetJSON("query", "info", "--config", d.BDDContext.clientConfigFilePath+d.BDDContext.clientConfigFileName, "--cid", channelID, "--orgid", orgID)
+	info := newQueryInfoResponse(resp)
+
+	return strconv.Atoi(info.Height)
+}
+
+func newQueryInfoResponse(resp *fabApi.BlockchainInfoResponse) *queryInfoResponse {
+	return &queryInfoResponse{
+		Height:            strconv.FormatUint(resp.BCI.Height, 10),
+		CurrentBlockHash:  hex.EncodeToString(resp.BCI.CurrentBlockHash),
+		PreviousBlockHash: hex.EncodeToString(resp.BCI.PreviousBlockHash),
+	}
+}
+
+func (d *CommonSteps) displayLastBlockFromChannel(channelID string) error {
+	return d.displayBlocksFromChannel(1, channelID)
+}
+
+func (d *CommonSteps) retrieveBlockFromChannel(blockNum int, channelID string) error {
+	_, err := d.getBlockAsResponse(channelID, blockNum)
+	return err
+}
+
+func (d *CommonSteps) retrieveLastBlockFromChannel(channelID string) error {
+	_, err := d.getLastBlockAsResponse(channelID)
+	return err
+}
+
+func (d *CommonSteps) blockContainsTxForChaincode(blockNum int, channelID, ccID string) error {
+	block, err := d.getBlockAsResponse(channelID, blockNum)
 	if err != nil {
-		return 0, err
+		return err
 	}
+	return checkBlockContainsTxForChaincode(block, ccID)
+}
 
-	var info queryInfoResponse
-	if err := json.Unmarshal([]byte(resp), &info); err != nil {
-		return 0, fmt.Errorf("Error unmarshalling JSON response: %s", err)
+func (d *CommonSteps) lastBlockContainsTxForChaincode(channelID, ccID string) error {
+	block, err := d.getLastBlockAsResponse(channelID)
+	if err != nil {
+		return err
 	}
+	return checkBlockContainsTxForChaincode(block, ccID)
+}
 
-	return strconv.Atoi(info.Height)
+func (d *CommonSteps) blockHasNumTransactions(blockNum int, channelID string, expectedNum int) error {
+	block, err := d.getBlockAsResponse(channelID, blockNum)
+	if err != nil {
+		return err
+	}
+
+	return checkBlockHasNumTransactions(block, expectedNum)
 }
 
-func (d *CommonSteps) displayLastBlockFromChannel(channelID string) error {
-	return d.displayBlocksFromChannel(1, channelID)
+func (d *CommonSteps) lastTxValidatedWithCode(channelID, expectedCode string) error {
+	if _, ok := pb.TxValidationCode_value[expectedCode]; !ok {
+		return errors.Errorf("invalid transaction validation code [%s]", expectedCode)
+	}
+
+	block, err := d.getLastBlockAsResponse(channelID)
+	if err != nil {
+		return err
+	}
+
+	return checkLastTxValidatedWithCode(block, expectedCode)
+}
+
+// getBlockAsResponse retrieves the given block and saves its JSON representation as the response
+// so that the response assertion steps may be applied to it
+func (d *CommonSteps) getBlockAsResponse(channelID string, blockNum int) (*Block, error) {
+	d.BDDContext.Vars().ClearResponse()
+
+	blocks, err := d.getBlocks(channelID, blockNum, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, errors.Errorf("block %d not found on channel [%s]", blockNum, channelID)
+	}
+
+	if err := d.setBlockAsResponse(blocks[0]); err != nil {
+		return nil, err
+	}
+
+	return blocks[0], nil
+}
+
+// setBlockAsResponse saves the JSON representation of the given block as the response
+func (d *CommonSteps) setBlockAsResponse(block *Block) error {
+	blockBytes, err := json.Marshal(block)
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling block %d", block.Number)
+	}
+	d.BDDContext.Vars().SetResponse(string(blockBytes))
+	return nil
+}
+
+func (d *CommonSteps) getLastBlockAsResponse(channelID string) (*Block, error) {
+	height, err := d.getChannelBlockHeight(channelID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error getting channel height")
+	}
+	return d.getBlockAsResponse(channelID, height-1)
+}
+
+func checkBlockContainsTxForChaincode(block *Block, ccID string) error {
+	for _, tx := range block.Transactions {
+		for _, action := range tx.Actions {
+			if action.ChaincodeID == ccID {
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("block %d does not contain a transaction for chaincode [%s]", block.Number, ccID)
+}
+
+func checkBlockHasNumTransactions(block *Block, expectedNum int) error {
+	if len(block.Transactions) != expectedNum {
+		return errors.Errorf("block %d has %d transaction(s) but expecting %d", block.Number, len(block.Transactions), expectedNum)
+	}
+	return nil
+}
+
+func checkLastTxValidatedWithCode(block *Block, expectedCode string) error {
+	if len(block.Transactions) == 0 {
+		return errors.Errorf("block %d has no transactions", block.Number)
+	}
+
+	tx := block.Transactions[len(block.Transactions)-1]
+	logger.Infof("Last transaction [%s] in block %d was validated with code [%s]", tx.TxID, block.Number, tx.ValidationCode)
+	if tx.ValidationCode != expectedCode {
+		return errors.Errorf("transaction [%s] in block %d was validated with code [%s] but expecting [%s]", tx.TxID, block.Number, tx.ValidationCode, expectedCode)
+	}
+	return nil
 }
 
 func (d *CommonSteps) wait(seconds int) error {
@@ -177,7 +346,7 @@ func (d *CommonSteps) createChannelAndJoinPeers(channelID string, orgs []string)
 			return fmt.Errorf("no peers for org [%s]", orgID)
 		}
 		if err := d.joinPeersToChannel(channelID, orgID, peersConfig); err != nil {
-			return fmt.Errorf("error joining peer to channel: %s", err)
+			return errors.WithMessage(err, "error joining peer to channel")
 		}
 
 	}
@@ -203,7 +372,7 @@ func (d *CommonSteps) joinPeersToChannel(channelID, orgID string, peersConfig []
 	// Check if primary peer has joined channel
 	alreadyJoined, err := HasPrimaryPeerJoinedChannel(channelID, resourceMgmt, d.BDDContext.OrgUserContext(orgID, ADMIN), peer)
 	if err != nil {
-		return fmt.Errorf("Error while checking if primary peer has already joined channel: %s", err)
+		return errors.WithMessage(err, "Error while checking if primary peer has already joined channel")
 	} else if alreadyJoined {
 		logger.Infof("alreadyJoined orgID [%s]\n", orgID)
 		return nil
@@ -249,7 +418,7 @@ func (d *CommonSteps) joinPeersToChannel(channelID, orgID string, peersConfig []
 
 	resMgmtClient := d.BDDContext.ResMgmtClient(orgID, ADMIN)
 	if err = resMgmtClient.JoinChannel(channelID, resmgmt.WithRetry(retry.DefaultResMgmtOpts)); err != nil {
-		return fmt.Errorf("JoinChannel returned error: %s", err)
+		return errors.WithMessage(err, "JoinChannel returned error")
 	}
 
 	return nil
@@ -257,25 +426,52 @@ func (d *CommonSteps) joinPeersToChannel(channelID, orgID string, peersConfig []
 
 // InvokeCConOrg invoke cc on org
 func (d *CommonSteps) InvokeCConOrg(ccID, args, orgIDs, channelID string) error {
-	argArr, err := ResolveAllVars(args)
+	return d.invokeCConOrgAsUser(ccID, args, orgIDs, channelID, USER, "")
+}
+
+func (d *CommonSteps) invokeCConOrgAsUser(ccID, args, orgIDs, channelID, userType, orgID string) error {
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
-	if _, err := d.InvokeCCWithArgs(ccID, channelID, d.OrgPeers(orgIDs, channelID), argArr, nil); err != nil {
-		return fmt.Errorf("InvokeCCWithArgs return error: %s", err)
+	if _, err := d.InvokeCCWithArgsAsUser(ccID, channelID, d.OrgPeers(orgIDs, channelID), argArr, nil, orgID, userType); err != nil {
+		return errors.WithMessage(err, "InvokeCCWithArgs return error")
 	}
 	return nil
 }
 
 // InvokeCC invoke cc
 func (d *CommonSteps) InvokeCC(ccID, args, channelID string) error {
-	argArr, err := ResolveAllVars(args)
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 	if _, err := d.InvokeCCWithArgs(ccID, channelID, nil, argArr, nil); err != nil {
-		return fmt.Errorf("InvokeCC return error: %s", err)
+		return errors.WithMessage(err, "InvokeCC return error")
+	}
+	return nil
+}
+
+func (d *CommonSteps) invokeCCAsUser(ccID, args, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.InvokeCCWithArgsAsUser(ccID, channelID, nil, argArr, nil, orgID, userType)
+	if err != nil {
+		return errors.WithMessage(err, "InvokeCC return error")
 	}
+	d.BDDContext.Vars().SetResponse(string(resp.Payload))
+	logger.Debugf("InvokeCCWithArgsAsUser returned value: [%s]", resp.Payload)
+	return nil
+}
+
+func (d *CommonSteps) registerUser(name, orgID, userName string) error {
+	logger.Infof("Registering user [%s] of org [%s] with the crypto material of user [%s]", name, orgID, userName)
+	d.BDDContext.RegisterUser(orgID, name, userName)
 	return nil
 }
 
@@ -291,6 +487,16 @@ func (d *CommonSteps) InvokeCCWithArgs(ccID, channelID string, targets []*PeerCo
 
 // invokeCCWithArgs ...
 func (d *CommonSteps) invokeCCWithArgs(ccID, channelID string, targets []*PeerConfig, args []string, transientData map[string][]byte, userType string) (channel.Response, error) {
+	return d.InvokeCCWithArgsAsUser(ccID, channelID, targets, args, transientData, "", userType)
+}
+
+// InvokeCCWithArgsAsUser invokes the chaincode with args as the given user of the given org. The user type may be
+// ADMIN, USER or the name of a registered identity. If the org is empty then the first org is used.
+func (d *CommonSteps) InvokeCCWithArgsAsUser(ccID, channelID string, targets []*PeerConfig, args []string, transientData map[string][]byte, orgID, userType string) (channel.Response, error) {
+	if orgID == "" {
+		orgID = d.BDDContext.orgs[0]
+	}
+
 	var peers []fabApi.Peer
 
 	for _, target := range targets {
@@ -301,9 +507,9 @@ func (d *CommonSteps) invokeCCWithArgs(ccID, channelID string, targets []*PeerCo
 		peers = append(peers, targetPeer)
 	}
 
-	chClient, err := d.BDDContext.OrgChannelClient(d.BDDContext.orgs[0], userType, channelID)
+	chClient, err := d.BDDContext.OrgChannelClient(orgID, userType, channelID)
 	if err != nil {
-		return channel.Response{}, fmt.Errorf("Failed to create new channel client: %s", err)
+		return channel.Response{}, errors.WithMessage(err, "Failed to create new channel client")
 	}
 
 	retryOpts := retry.DefaultOpts
@@ -313,18 +519,24 @@ func (d *CommonSteps) invokeCCWithArgs(ccID, channelID string, targets []*PeerCo
 		addRetryCode(retryOpts.RetryableCodes, status.ChaincodeStatus, status.Code(code))
 	}
 
+	ccArgs, err := DecodeArgs(args[1:])
+	if err != nil {
+		return channel.Response{}, err
+	}
+
 	response, err := chClient.Execute(
 		channel.Request{
-			ChaincodeID: ccID,
-			Fcn:         args[0],
-			Args:        GetByteArgs(args[1:]),
+			ChaincodeID:  ccID,
+			Fcn:          args[0],
+			Args:         ccArgs,
+			TransientMap: transientData,
 		},
 		channel.WithTargets(peers...),
 		channel.WithRetry(retryOpts),
 	)
 
 	if err != nil {
-		return channel.Response{}, fmt.Errorf("InvokeChaincode return error: %s", err)
+		return channel.Response{}, errors.WithMessage(err, "InvokeChaincode return error")
 	}
 	return response, nil
 }
@@ -339,23 +551,32 @@ func addRetryCode(codes map[status.Group][]status.Code, group status.Group, code
 }
 
 func (d *CommonSteps) queryCConOrg(ccID, args, orgIDs, channelID string) error {
-	queryValue = ""
+	return d.queryCConOrgAsUser(ccID, args, orgIDs, channelID, ADMIN, "")
+}
 
-	argArr, err := ResolveAllVars(args)
+func (d *CommonSteps) queryCConOrgAsUser(ccID, args, orgIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 
-	queryValue, err = d.QueryCCWithArgs(false, ccID, channelID, argArr, nil, d.OrgPeers(orgIDs, channelID)...)
+	response, err := d.queryCCWithArgsAsUser(false, ccID, channelID, argArr, nil, orgID, userType, d.OrgPeers(orgIDs, channelID)...)
 	if err != nil {
-		return fmt.Errorf("QueryCCWithArgs return error: %s", err)
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
 	}
-	logger.Debugf("QueryCCWithArgs return value: [%s]", queryValue)
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Debugf("QueryCCWithArgs return value: [%s]", response)
 	return nil
 }
 
 func (d *CommonSteps) queryCConTargetPeers(ccID, args, peerIDs, channelID string) error {
-	queryValue = ""
+	return d.queryCConTargetPeersAsUser(ccID, args, peerIDs, channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) queryCConTargetPeersAsUser(ccID, args, peerIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
 
 	if peerIDs == "" {
 		return errors.New("no target peers specified")
@@ -368,21 +589,26 @@ func (d *CommonSteps) queryCConTargetPeers(ccID, args, peerIDs, channelID string
 
 	logger.Debugf("Querying peers [%s]...", targetPeers)
 
-	argArr, err := ResolveAllVars(args)
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 
-	queryValue, err = d.QueryCCWithArgs(false, ccID, channelID, argArr, nil, targetPeers...)
+	response, err := d.queryCCWithArgsAsUser(false, ccID, channelID, argArr, nil, orgID, userType, targetPeers...)
 	if err != nil {
-		return fmt.Errorf("QueryCCWithArgs return error: %s", err)
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
 	}
-	logger.Debugf("QueryCCWithArgs return value: [%s]", queryValue)
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Debugf("QueryCCWithArgs return value: [%s]", response)
 	return nil
 }
 
 func (d *CommonSteps) invokeCConTargetPeers(ccID, args, peerIDs, channelID string) error {
-	queryValue = ""
+	return d.invokeCConTargetPeersAsUser(ccID, args, peerIDs, channelID, USER, "")
+}
+
+func (d *CommonSteps) invokeCConTargetPeersAsUser(ccID, args, peerIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
 
 	if peerIDs == "" {
 		return errors.New("no target peers specified")
@@ -395,22 +621,26 @@ func (d *CommonSteps) invokeCConTargetPeers(ccID, args, peerIDs, channelID strin
 
 	logger.Debugf("Invoking peers [%s]...", targetPeers)
 
-	argArr, err := ResolveAllVars(args)
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 
-	resp, err := d.InvokeCCWithArgs(ccID, channelID, targetPeers, argArr, nil)
+	resp, err := d.InvokeCCWithArgsAsUser(ccID, channelID, targetPeers, argArr, nil, orgID, userType)
 	if err != nil {
-		return fmt.Errorf("InvokeCCWithArgs returned error: %s", err)
+		return errors.WithMessage(err, "InvokeCCWithArgs returned error")
 	}
-	queryValue = string(resp.Payload)
-	logger.Debugf("InvokeCCWithArgs returned value: [%s]", queryValue)
+	d.BDDContext.Vars().SetResponse(string(resp.Payload))
+	logger.Debugf("InvokeCCWithArgs returned value: [%s]", resp.Payload)
 	return nil
 }
 
 func (d *CommonSteps) queryCConSinglePeerInOrg(ccID, args, orgIDs, channelID string) error {
-	queryValue = ""
+	return d.queryCConSinglePeerInOrgAsUser(ccID, args, orgIDs, channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) queryCConSinglePeerInOrgAsUser(ccID, args, orgIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
 
 	targetPeers := d.OrgPeers(orgIDs, channelID)
 	if len(targetPeers) == 0 {
@@ -422,25 +652,30 @@ func (d *CommonSteps) queryCConSinglePeerInOrg(ccID, args, orgIDs, channelID str
 
 	logger.Infof("Querying peer [%s]...", targetPeer.Config.URL)
 
-	argArr, err := ResolveAllVars(args)
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 
-	queryValue, err = d.QueryCCWithArgs(false, ccID, channelID, argArr, nil, targetPeer)
+	response, err := d.queryCCWithArgsAsUser(false, ccID, channelID, argArr, nil, orgID, userType, targetPeer)
 	if err != nil {
-		return fmt.Errorf("QueryCCWithArgs return error: %s", err)
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
 	}
-	logger.Debugf("QueryCCWithArgs return value: [%s]", queryValue)
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Debugf("QueryCCWithArgs return value: [%s]", response)
 	return nil
 }
 
-func (d *CommonSteps) querySystemCC(ccID, args, orgID, channelID string) error {
-	queryValue = ""
+func (d *CommonSteps) querySystemCC(ccID, args, peerOrgID, channelID string) error {
+	return d.querySystemCCAsUser(ccID, args, peerOrgID, channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) querySystemCCAsUser(ccID, args, peerOrgID, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
 
-	peersConfig, ok := d.BDDContext.clientConfig.PeersConfig(orgID)
+	peersConfig, ok := d.BDDContext.clientConfig.PeersConfig(peerOrgID)
 	if !ok {
-		return fmt.Errorf("could not get peers config for org [%s]", orgID)
+		return fmt.Errorf("could not get peers config for org [%s]", peerOrgID)
 	}
 
 	serverHostOverride := ""
@@ -448,48 +683,259 @@ func (d *CommonSteps) querySystemCC(ccID, args, orgID, channelID string) error {
 		serverHostOverride = str
 	}
 
-	argsArray, err := ResolveAllVars(args)
+	argsArray, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
 
-	queryValue, err = d.QueryCCWithArgs(true, ccID, channelID, argsArray, nil,
-		[]*PeerConfig{{Config: peersConfig[0], OrgID: orgID, MspID: d.BDDContext.peersMspID[serverHostOverride], PeerID: serverHostOverride}}...)
+	response, err := d.queryCCWithArgsAsUser(true, ccID, channelID, argsArray, nil, orgID, userType,
+		[]*PeerConfig{{Config: peersConfig[0], OrgID: peerOrgID, MspID: d.BDDContext.peersMspID[serverHostOverride], PeerID: serverHostOverride}}...)
 	if err != nil {
-		return fmt.Errorf("QueryCCWithArgs return error: %s", err)
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
 	}
-	logger.Debugf("QueryCCWithArgs return value: [%s]", queryValue)
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Debugf("QueryCCWithArgs return value: [%s]", response)
 	return nil
 }
 
 func (d *CommonSteps) queryCC(ccID, args, channelID string) error {
 	logger.Infof("Querying chaincode [%s] on channel [%s] with args [%s]", ccID, channelID, args)
 
-	queryValue = ""
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	response, err := d.QueryCCWithArgs(false, ccID, channelID, argArr, nil)
+	if err != nil {
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
+	}
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Infof("QueryCC return value: [%s]", response)
+	return nil
+}
+
+func (d *CommonSteps) invokeCCWithDocString(ccID, channelID string, doc *gherkin.DocString) error {
+	return d.invokeCCWithArgArray(ccID, channelID, DocStringArgs(doc), USER, "")
+}
+
+func (d *CommonSteps) invokeCCWithDataTable(ccID, channelID string, table *gherkin.DataTable) error {
+	return d.invokeCCWithArgArray(ccID, channelID, DataTableArgs(table), USER, "")
+}
+
+func (d *CommonSteps) invokeCCWithDocStringAsUser(ccID, channelID, userType, orgID string, doc *gherkin.DocString) error {
+	return d.invokeCCWithArgArray(ccID, channelID, DocStringArgs(doc), userType, orgID)
+}
+
+func (d *CommonSteps) invokeCCWithDataTableAsUser(ccID, channelID, userType, orgID string, table *gherkin.DataTable) error {
+	return d.invokeCCWithArgArray(ccID, channelID, DataTableArgs(table), userType, orgID)
+}
+
+func (d *CommonSteps) invokeCCWithArgArray(ccID, channelID string, args []string, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAll(args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.InvokeCCWithArgsAsUser(ccID, channelID, nil, argArr, nil, orgID, userType)
+	if err != nil {
+		return errors.WithMessage(err, "InvokeCC return error")
+	}
+	d.BDDContext.Vars().SetResponse(string(resp.Payload))
+	logger.Debugf("InvokeCCWithArgs returned value: [%s]", resp.Payload)
+	return nil
+}
+
+func (d *CommonSteps) queryCCWithDocString(ccID, channelID string, doc *gherkin.DocString) error {
+	return d.queryCCWithArgArray(ccID, channelID, DocStringArgs(doc), ADMIN, "")
+}
+
+func (d *CommonSteps) queryCCWithDataTable(ccID, channelID string, table *gherkin.DataTable) error {
+	return d.queryCCWithArgArray(ccID, channelID, DataTableArgs(table), ADMIN, "")
+}
+
+func (d *CommonSteps) queryCCWithDocStringAsUser(ccID, channelID, userType, orgID string, doc *gherkin.DocString) error {
+	return d.queryCCWithArgArray(ccID, channelID, DocStringArgs(doc), userType, orgID)
+}
+
+func (d *CommonSteps) queryCCWithDataTableAsUser(ccID, channelID, userType, orgID string, table *gherkin.DataTable) error {
+	return d.queryCCWithArgArray(ccID, channelID, DataTableArgs(table), userType, orgID)
+}
+
+func (d *CommonSteps) queryCCWithArgArray(ccID, channelID string, args []string, userType, orgID string) error {
+	logger.Infof("Querying chaincode [%s] on channel [%s] with args %s", ccID, channelID, args)
+
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAll(args)
+	if err != nil {
+		return err
+	}
+
+	response, err := d.queryCCWithArgsAsUser(false, ccID, channelID, argArr, nil, orgID, userType)
+	if err != nil {
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
+	}
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Infof("QueryCC return value: [%s]", response)
+	return nil
+}
+
+func (d *CommonSteps) defineTransientMap(name string, table *gherkin.DataTable) error {
+	transientMap := make(map[string][]byte)
+	for _, row := range table.Rows {
+		if len(row.Cells) != 2 {
+			return errors.Errorf("each row of transient map [%s] must contain a key and a value", name)
+		}
+
+		key, err := d.BDDContext.Vars().Resolve(row.Cells[0].Value)
+		if err != nil {
+			return err
+		}
+
+		value, err := d.BDDContext.Vars().Resolve(row.Cells[1].Value)
+		if err != nil {
+			return err
+		}
+
+		transientMap[key], err = DecodeArg(value)
+		if err != nil {
+			return err
+		}
+	}
 
-	argArr, err := ResolveAllVars(args)
+	logger.Infof("Defining transient map [%s] with keys %s", name, transientMapKeys(transientMap))
+	d.BDDContext.DefineTransientMap(name, transientMap)
+	return nil
+}
+
+func (d *CommonSteps) transientMap(name string) (map[string][]byte, error) {
+	transientMap := d.BDDContext.TransientMap(name)
+	if transientMap == nil {
+		return nil, errors.Errorf("transient map [%s] is not defined", name)
+	}
+	return transientMap, nil
+}
+
+func (d *CommonSteps) invokeCCWithTransientMap(ccID, args, name, channelID string) error {
+	return d.invokeCCWithTransientMapOnPeersAsUser(ccID, args, name, "", channelID, USER, "")
+}
+
+func (d *CommonSteps) invokeCCWithTransientMapAsUser(ccID, args, name, channelID, userType, orgID string) error {
+	return d.invokeCCWithTransientMapOnPeersAsUser(ccID, args, name, "", channelID, userType, orgID)
+}
+
+func (d *CommonSteps) invokeCCWithTransientMapOnPeers(ccID, args, name, peerIDs, channelID string) error {
+	return d.invokeCCWithTransientMapOnPeersAsUser(ccID, args, name, peerIDs, channelID, USER, "")
+}
+
+func (d *CommonSteps) invokeCCWithTransientMapOnPeersAsUser(ccID, args, name, peerIDs, channelID, userType, orgID string) error {
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
 	if err != nil {
 		return err
 	}
+	return d.invokeCCWithArgArrayAndTransientMap(ccID, argArr, name, peerIDs, channelID, userType, orgID)
+}
+
+func (d *CommonSteps) invokeCCWithArgArrayAndTransientMap(ccID string, argArr []string, name, peerIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
 
-	queryValue, err = d.QueryCCWithArgs(false, ccID, channelID, argArr, nil)
+	transientMap, err := d.transientMap(name)
 	if err != nil {
-		return fmt.Errorf("QueryCCWithArgs return error: %s", err)
+		return err
+	}
+
+	var targetPeers []*PeerConfig
+	if peerIDs != "" {
+		targetPeers, err = d.Peers(peerIDs)
+		if err != nil {
+			return err
+		}
 	}
-	logger.Infof("QueryCC return value: [%s]", queryValue)
+
+	resp, err := d.InvokeCCWithArgsAsUser(ccID, channelID, targetPeers, argArr, transientMap, orgID, userType)
+	if err != nil {
+		return errors.WithMessage(err, "InvokeCCWithArgs returned error")
+	}
+	d.BDDContext.Vars().SetResponse(string(resp.Payload))
+	logger.Debugf("InvokeCCWithArgs returned value: [%s]", resp.Payload)
 	return nil
 }
 
-func (d *CommonSteps) queryCCWithError(ccID, args, channelID string, expectedError string) error {
-	err := d.queryCC(ccID, args, channelID)
-	if err == nil {
-		return errors.Errorf("expecting error [%s] but got no error", expectedError)
+func (d *CommonSteps) queryCCWithTransientMap(ccID, args, name, channelID string) error {
+	return d.queryCCWithTransientMapOnPeersAsUser(ccID, args, name, "", channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) queryCCWithTransientMapAsUser(ccID, args, name, channelID, userType, orgID string) error {
+	return d.queryCCWithTransientMapOnPeersAsUser(ccID, args, name, "", channelID, userType, orgID)
+}
+
+func (d *CommonSteps) queryCCWithTransientMapOnPeers(ccID, args, name, peerIDs, channelID string) error {
+	return d.queryCCWithTransientMapOnPeersAsUser(ccID, args, name, peerIDs, channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) queryCCWithTransientMapOnPeersAsUser(ccID, args, name, peerIDs, channelID, userType, orgID string) error {
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+	return d.queryCCWithArgArrayAndTransientMap(ccID, argArr, name, peerIDs, channelID, userType, orgID)
+}
+
+func (d *CommonSteps) queryCCWithArgArrayAndTransientMap(ccID string, argArr []string, name, peerIDs, channelID, userType, orgID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	transientMap, err := d.transientMap(name)
+	if err != nil {
+		return err
+	}
+
+	var targetPeers []*PeerConfig
+	if peerIDs != "" {
+		targetPeers, err = d.Peers(peerIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	response, err := d.queryCCWithArgsAsUser(false, ccID, channelID, argArr, transientMap, orgID, userType, targetPeers...)
+	if err != nil {
+		return errors.WithMessage(err, "QueryCCWithArgs return error")
+	}
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Debugf("QueryCCWithArgs return value: [%s]", response)
+	return nil
+}
+
+func transientMapKeys(transientMap map[string][]byte) []string {
+	var keys []string
+	for key := range transientMap {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *CommonSteps) queryCCAsUser(ccID, args, channelID, userType, orgID string) error {
+	logger.Infof("Querying chaincode [%s] on channel [%s] with args [%s] as user [%s] of org [%s]", ccID, channelID, args, userType, orgID)
 
-	if !strings.Contains(err.Error(), expectedError) {
-		return errors.Errorf("expecting error [%s] but got [%s]", expectedError, err)
+	d.BDDContext.Vars().ClearResponse()
+
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
 	}
 
+	response, err := d.QueryCCAsUser(ccID, channelID, argArr, nil, orgID, userType)
+	if err != nil {
+		return errors.WithMessage(err, "QueryCCAsUser return error")
+	}
+	d.BDDContext.Vars().SetResponse(response)
+	logger.Infof("QueryCCAsUser return value: [%s]", response)
 	return nil
 }
 
@@ -500,6 +946,23 @@ func (d *CommonSteps) QueryCCWithArgs(systemCC bool, ccID, channelID string, arg
 
 // QueryCCWithOpts ...
 func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, args []string, timeout time.Duration, concurrent bool, interval time.Duration, transientData map[string][]byte, targets ...*PeerConfig) (string, error) {
+	return d.queryCCWithOpts(systemCC, ccID, channelID, args, timeout, concurrent, interval, transientData, "", ADMIN, targets...)
+}
+
+// QueryCCAsUser queries the chaincode with args as the given user of the given org. The user type may be
+// ADMIN, USER or the name of a registered identity.
+func (d *CommonSteps) QueryCCAsUser(ccID, channelID string, args []string, transientData map[string][]byte, orgID, userType string, targets ...*PeerConfig) (string, error) {
+	return d.queryCCWithArgsAsUser(false, ccID, channelID, args, transientData, orgID, userType, targets...)
+}
+
+func (d *CommonSteps) queryCCWithArgsAsUser(systemCC bool, ccID, channelID string, args []string, transientData map[string][]byte, orgID, userType string, targets ...*PeerConfig) (string, error) {
+	return d.queryCCWithOpts(systemCC, ccID, channelID, args, 0, true, 0, transientData, orgID, userType, targets...)
+}
+
+// queryCCWithOpts queries the chaincode as the given user of the given org. If the org is not specified
+// then the org of the target peers (or the first org) is used. System chaincode proposals are signed by
+// USER unless the org is specified, in which case they're signed by the given user.
+func (d *CommonSteps) queryCCWithOpts(systemCC bool, ccID, channelID string, args []string, timeout time.Duration, concurrent bool, interval time.Duration, transientData map[string][]byte, clientOrgID, userType string, targets ...*PeerConfig) (string, error) {
 	var peers []fabApi.Peer
 	var orgID string
 	var queryResult string
@@ -518,7 +981,13 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 		orgID = d.BDDContext.orgs[0]
 	}
 
-	chClient, err := d.BDDContext.OrgChannelClient(orgID, ADMIN, channelID)
+	sysCCUserType := USER
+	if clientOrgID != "" {
+		orgID = clientOrgID
+		sysCCUserType = userType
+	}
+
+	chClient, err := d.BDDContext.OrgChannelClient(orgID, userType, channelID)
 	if err != nil {
 		logger.Errorf("Failed to create new channel client: %s", err)
 		return "", errors.Wrap(err, "Failed to create new channel client")
@@ -531,23 +1000,33 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 		addRetryCode(retryOpts.RetryableCodes, status.ChaincodeStatus, status.Code(code))
 	}
 
+	ccArgs, err := DecodeArgs(args[1:])
+	if err != nil {
+		return "", err
+	}
+
 	if systemCC {
 		// Create a system channel client
 
+		sysCCContext := d.BDDContext.OrgUserContext(orgID, sysCCUserType)
+		if sysCCContext == nil {
+			return "", errors.Errorf("unable to get context for user [%s] of org [%s]", sysCCUserType, orgID)
+		}
+
 		systemHandlerChain := invoke.NewProposalProcessorHandler(
 			NewCustomEndorsementHandler(
-				d.BDDContext.OrgUserContext(orgID, USER),
+				sysCCContext,
 				invoke.NewEndorsementValidationHandler(),
 			))
 
 		resp, err := chClient.InvokeHandler(systemHandlerChain, channel.Request{
 			ChaincodeID:  ccID,
 			Fcn:          args[0],
-			Args:         GetByteArgs(args[1:]),
+			Args:         ccArgs,
 			TransientMap: transientData,
 		}, channel.WithTargets(peers...), channel.WithTimeout(fabApi.Execute, timeout), channel.WithRetry(retryOpts))
 		if err != nil {
-			return "", fmt.Errorf("QueryChaincode return error: %s", err)
+			return "", errors.WithMessage(err, "QueryChaincode return error")
 		}
 		queryResult = string(resp.Payload)
 		return queryResult, nil
@@ -558,11 +1037,11 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 		resp, err := chClient.Query(channel.Request{
 			ChaincodeID:  ccID,
 			Fcn:          args[0],
-			Args:         GetByteArgs(args[1:]),
+			Args:         ccArgs,
 			TransientMap: transientData,
 		}, channel.WithTargets(peers...), channel.WithTimeout(fabApi.Execute, timeout), channel.WithRetry(retryOpts))
 		if err != nil {
-			return "", fmt.Errorf("QueryChaincode return error: %s", err)
+			return "", errors.WithMessage(err, "QueryChaincode return error")
 		}
 		queryResult = string(resp.Payload)
 
@@ -575,7 +1054,7 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 			resp, err := chClient.Query(channel.Request{
 				ChaincodeID:  ccID,
 				Fcn:          args[0],
-				Args:         GetByteArgs(args[1:]),
+				Args:         ccArgs,
 				TransientMap: transientData,
 			}, channel.WithTargets([]fabApi.Peer{peer}...), channel.WithTimeout(fabApi.Execute, timeout), channel.WithRetry(retryOpts))
 			if err != nil {
@@ -589,7 +1068,7 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 			}
 		}
 		if len(errs) > 0 {
-			return "", fmt.Errorf("QueryChaincode return error: %s", errs[0])
+			return "", errors.WithMessage(errs[0], "QueryChaincode return error")
 		}
 	}
 
@@ -598,6 +1077,7 @@ func (d *CommonSteps) QueryCCWithOpts(systemCC bool, ccID, channelID string, arg
 }
 
 func (d *CommonSteps) containsInQueryValue(ccID string, value string) error {
+	queryValue := d.BDDContext.Vars().Response()
 	logger.Infof("Query value %s and tested value %s", queryValue, value)
 	if !strings.Contains(queryValue, value) {
 		return fmt.Errorf("Query value(%s) doesn't contain expected value(%s)", queryValue, value)
@@ -606,6 +1086,7 @@ func (d *CommonSteps) containsInQueryValue(ccID string, value string) error {
 }
 
 func (d *CommonSteps) equalQueryValue(ccID string, value string) error {
+	queryValue := d.BDDContext.Vars().Response()
 	logger.Infof("Query value %s and tested value %s", queryValue, value)
 	if queryValue == value {
 		return nil
@@ -614,8 +1095,41 @@ func (d *CommonSteps) equalQueryValue(ccID string, value string) error {
 }
 
 func (d *CommonSteps) setVariableFromCCResponse(key string) error {
+	queryValue := d.BDDContext.Vars().Response()
 	logger.Infof("Saving value %s to variable %s", queryValue, key)
-	SetVar(key, queryValue)
+	d.BDDContext.Vars().Set(key, queryValue)
+	return nil
+}
+
+func (d *CommonSteps) setVariableFromCCResponseWithEncoding(key, encoding string) error {
+	value, err := EncodeValue([]byte(d.BDDContext.Vars().Response()), encoding)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Saving %s-encoded response %s to variable %s", encoding, value, key)
+	d.BDDContext.Vars().Set(key, value)
+	return nil
+}
+
+func (d *CommonSteps) setVariable(varName, value string) error {
+	return d.setVariableInScope(ScenarioScope, varName, value)
+}
+
+func (d *CommonSteps) setFeatureVariable(varName, value string) error {
+	return d.setVariableInScope(FeatureScope, varName, value)
+}
+
+func (d *CommonSteps) setSuiteVariable(varName, value string) error {
+	return d.setVariableInScope(SuiteScope, varName, value)
+}
+
+func (d *CommonSteps) setVariableInScope(scope VarScope, varName, value string) error {
+	value, err := d.BDDContext.Vars().Resolve(value)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Saving value %s to %s variable %s", value, scope, varName)
+	d.BDDContext.Vars().SetInScope(scope, varName, value)
 	return nil
 }
 
@@ -625,20 +1139,27 @@ func (d *CommonSteps) setJSONVariable(varName, value string) error {
 	if err := json.Unmarshal([]byte(value), &m); err != nil {
 		return errors.WithMessagef(err, "invalid JSON: %s", value)
 	}
-	SetVar(varName, value)
+	d.BDDContext.Vars().Set(varName, value)
 	return nil
 }
 
 func (d *CommonSteps) jsonPathOfCCResponseEquals(path, expected string) error {
+	expected, err := d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+
+	queryValue := d.BDDContext.Vars().Response()
 	r := gjson.Get(queryValue, path)
-	logger.Infof("Path [%s] of JSON %s resolves to %s", path, queryValue, r.Str)
-	if r.Str == expected {
+	logger.Infof("Path [%s] of JSON %s resolves to %s", path, queryValue, r.String())
+	if r.String() == expected {
 		return nil
 	}
-	return fmt.Errorf("JSON path resolves to [%s] which is not the expected value [%s]", r.Str, expected)
+	return fmt.Errorf("JSON path resolves to [%s] which is not the expected value [%s]", r.String(), expected)
 }
 
 func (d *CommonSteps) jsonPathOfCCHasNumItems(path string, expectedNum int) error {
+	queryValue := d.BDDContext.Vars().Response()
 	r := gjson.Get(queryValue, path)
 	logger.Infof("Path [%s] of JSON %s resolves to %d items", path, queryValue, int(r.Num))
 	if int(r.Num) == expectedNum {
@@ -648,10 +1169,16 @@ func (d *CommonSteps) jsonPathOfCCHasNumItems(path string, expectedNum int) erro
 }
 
 func (d *CommonSteps) jsonPathOfCCResponseContains(path, expected string) error {
+	expected, err := d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+
+	queryValue := d.BDDContext.Vars().Response()
 	r := gjson.Get(queryValue, path)
 	logger.Infof("Path [%s] of JSON %s resolves to %s", path, queryValue, r.Raw)
 	for _, a := range r.Array() {
-		if a.Str == expected {
+		if a.String() == expected {
 			return nil
 		}
 	}
@@ -663,41 +1190,64 @@ func (d *CommonSteps) installChaincodeToAllPeers(ccType, ccID, ccPath string) er
 	return d.doInstallChaincodeToOrg(ccType, ccID, ccPath, "v1", "", "")
 }
 
+func (d *CommonSteps) installChaincodeToAllPeersAsUser(ccType, ccID, ccPath, userType, orgID string) error {
+	logger.Infof("Installing chaincode [%s] from path [%s] to all peers as user [%s] of org [%s]", ccID, ccPath, userType, orgID)
+	return d.doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, "v1", "", "", userType, orgID)
+}
+
 func (d *CommonSteps) installChaincodeToAllPeersWithVersion(ccType, ccID, ccVersion, ccPath string) error {
 	logger.Infof("Installing chaincode [%s:%s] from path [%s] to all peers", ccID, ccVersion, ccPath)
 	return d.doInstallChaincodeToOrg(ccType, ccID, ccPath, ccVersion, "", "")
 }
 
+func (d *CommonSteps) installChaincodeToAllPeersWithVersionAsUser(ccType, ccID, ccVersion, ccPath, userType, orgID string) error {
+	logger.Infof("Installing chaincode [%s:%s] from path [%s] to all peers as user [%s] of org [%s]", ccID, ccVersion, ccPath, userType, orgID)
+	return d.doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, ccVersion, "", "", userType, orgID)
+}
+
 func (d *CommonSteps) installChaincodeToAllPeersExcept(ccType, ccID, ccPath, blackListRegex string) error {
 	logger.Infof("Installing chaincode [%s] from path [%s] to all peers except [%s]", ccID, ccPath, blackListRegex)
 	return d.doInstallChaincodeToOrg(ccType, ccID, ccPath, "v1", "", blackListRegex)
 }
 
+func (d *CommonSteps) installChaincodeToAllPeersExceptAsUser(ccType, ccID, ccPath, blackListRegex, userType, orgID string) error {
+	logger.Infof("Installing chaincode [%s] from path [%s] to all peers except [%s] as user [%s] of org [%s]", ccID, ccPath, blackListRegex, userType, orgID)
+	return d.doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, "v1", "", blackListRegex, userType, orgID)
+}
+
 func (d *CommonSteps) instantiateChaincode(ccType, ccID, ccPath, channelID, args, ccPolicy, collectionNames string) error {
 	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames)
-	return d.instantiateChaincodeWithOpts(ccType, ccID, ccPath, "", channelID, args, ccPolicy, collectionNames, false)
+	return d.instantiateChaincodeWithArgArray(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames, SplitArgs(args))
 }
 
-func (d *CommonSteps) upgradeChaincode(ccType, ccID, ccVersion, ccPath, channelID, args, ccPolicy, collectionNames string) error {
-	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames)
-	return d.upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath, "", channelID, args, ccPolicy, collectionNames, false)
+func (d *CommonSteps) instantiateChaincodeWithDocString(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames string, doc *gherkin.DocString) error {
+	return d.instantiateChaincodeWithArgArray(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames, DocStringArgs(doc))
 }
 
-func (d *CommonSteps) upgradeChaincodeWithError(ccType, ccID, ccVersion, ccPath, channelID, args, ccPolicy, collectionNames, expectedError string) error {
-	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]. Expected error [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames, expectedError)
-	err := d.upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath, "", channelID, args, ccPolicy, collectionNames, false)
-	if err == nil {
-		return errors.Errorf("expecting error [%s] but got no error", expectedError)
-	}
-	if !strings.Contains(err.Error(), expectedError) {
-		return errors.Errorf("expecting error [%s] but got [%s]", expectedError, err)
+func (d *CommonSteps) instantiateChaincodeWithDataTable(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames string, table *gherkin.DataTable) error {
+	return d.instantiateChaincodeWithArgArray(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames, DataTableArgs(table))
+}
+
+func (d *CommonSteps) instantiateChaincodeWithArgArray(ccType, ccID, ccPath, channelID, ccPolicy, collectionNames string, args []string) error {
+	argArr, err := d.BDDContext.Vars().ResolveAll(args)
+	if err != nil {
+		return err
 	}
-	return nil
+	return d.instantiateChaincodeWithOpts(ccType, ccID, ccPath, "", channelID, argArr, ccPolicy, collectionNames, false)
+}
+
+func (d *CommonSteps) upgradeChaincode(ccType, ccID, ccVersion, ccPath, channelID, args, ccPolicy, collectionNames string) error {
+	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames)
+	return d.upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath, "", channelID, args, ccPolicy, collectionNames, false)
 }
 
 func (d *CommonSteps) instantiateChaincodeOnOrg(ccType, ccID, ccPath, orgIDs, channelID, args, ccPolicy, collectionNames string) error {
 	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] to orgs [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]", ccID, ccPath, orgIDs, channelID, args, ccPolicy, collectionNames)
-	return d.instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs, channelID, args, ccPolicy, collectionNames, false)
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+	return d.instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs, channelID, argArr, ccPolicy, collectionNames, false)
 }
 
 func (d *CommonSteps) deployChaincode(ccType, ccID, ccPath, channelID, args, ccPolicy, collectionPolicy string) error {
@@ -709,7 +1259,17 @@ func (d *CommonSteps) installChaincodeToOrg(ccType, ccID, ccPath, orgIDs string)
 	return d.doInstallChaincodeToOrg(ccType, ccID, ccPath, "v1", orgIDs, "")
 }
 
+func (d *CommonSteps) installChaincodeToOrgAsUser(ccType, ccID, ccPath, userType, orgID string) error {
+	return d.doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, "v1", orgID, "", userType, orgID)
+}
+
 func (d *CommonSteps) doInstallChaincodeToOrg(ccType, ccID, ccPath, ccVersion, orgIDs, blackListRegex string) error {
+	return d.doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, ccVersion, orgIDs, blackListRegex, ADMIN, "")
+}
+
+// doInstallChaincodeToOrgAsUser installs the chaincode to the peers of the given orgs (or all orgs if orgIDs is empty)
+// as the given user of userOrgID. If userOrgID is empty then the given user of each of the orgs is used.
+func (d *CommonSteps) doInstallChaincodeToOrgAsUser(ccType, ccID, ccPath, ccVersion, orgIDs, blackListRegex, userType, userOrgID string) error {
 	logger.Infof("Preparing to install chaincode [%s:%s] from path [%s] to orgs [%s] - Blacklisted peers: [%s]", ccID, ccPath, ccVersion, orgIDs, blackListRegex)
 
 	var oIDs []string
@@ -725,7 +1285,15 @@ func (d *CommonSteps) doInstallChaincodeToOrg(ccType, ccID, ccPath, ccVersion, o
 			return err
 		}
 
-		resMgmtClient := d.BDDContext.ResMgmtClient(orgID, ADMIN)
+		clientOrgID := userOrgID
+		if clientOrgID == "" {
+			clientOrgID = orgID
+		}
+
+		resMgmtClient, err := d.BDDContext.OrgResMgmtClient(clientOrgID, userType)
+		if err != nil {
+			return err
+		}
 
 		ccPkg, err := gopackager.NewCCPackage(ccPath, d.getDeployPath(ccType))
 		if err != nil {
@@ -743,7 +1311,7 @@ func (d *CommonSteps) doInstallChaincodeToOrg(ccType, ccID, ccPath, ccVersion, o
 			resmgmt.WithTargetEndpoints(targets...),
 		)
 		if err != nil {
-			return fmt.Errorf("SendInstallProposal return error: %s", err)
+			return errors.WithMessage(err, "SendInstallProposal return error")
 		}
 	}
 	return nil
@@ -794,7 +1362,7 @@ func getLocalTargets(context *BDDContext, orgID string, blackListRegex string) (
 	return peerURLs, nil
 }
 
-func (d *CommonSteps) instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs, channelID, args, ccPolicy, collectionNames string, allPeers bool) error {
+func (d *CommonSteps) instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs, channelID string, args []string, ccPolicy, collectionNames string, allPeers bool) error {
 	logger.Infof("Preparing to instantiate chaincode [%s] from path [%s] to orgs [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s]", ccID, ccPath, orgIDs, channelID, args, ccPolicy, collectionNames)
 
 	peers := d.OrgPeers(orgIDs, channelID)
@@ -803,7 +1371,7 @@ func (d *CommonSteps) instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs,
 	}
 	chaincodePolicy, err := d.newChaincodePolicy(ccPolicy, channelID)
 	if err != nil {
-		return fmt.Errorf("error creating endorsement policy: %s", err)
+		return errors.WithMessage(err, "error creating endorsement policy")
 	}
 
 	var sdkPeers []fabApi.Peer
@@ -838,6 +1406,11 @@ func (d *CommonSteps) instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs,
 
 	resMgmtClient := d.BDDContext.ResMgmtClient(orgID, ADMIN)
 
+	ccArgs, err := DecodeArgs(args)
+	if err != nil {
+		return err
+	}
+
 	logger.Infof("Instantiating chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s] to the following peers: [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames, peersAsString(sdkPeers))
 
 	_, err = resMgmtClient.InstantiateCC(
@@ -846,7 +1419,7 @@ func (d *CommonSteps) instantiateChaincodeWithOpts(ccType, ccID, ccPath, orgIDs,
 			Name:       ccID,
 			Path:       ccPath,
 			Version:    "v1",
-			Args:       GetByteArgs(strings.Split(args, ",")),
+			Args:       ccArgs,
 			Policy:     chaincodePolicy,
 			CollConfig: collConfig,
 		},
@@ -871,7 +1444,7 @@ func (d *CommonSteps) upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath,
 	}
 	chaincodePolicy, err := d.newChaincodePolicy(ccPolicy, channelID)
 	if err != nil {
-		return fmt.Errorf("error creating endorsement policy: %s", err)
+		return errors.WithMessage(err, "error creating endorsement policy")
 	}
 
 	var sdkPeers []fabApi.Peer
@@ -906,6 +1479,16 @@ func (d *CommonSteps) upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath,
 
 	resMgmtClient := d.BDDContext.ResMgmtClient(orgID, ADMIN)
 
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	ccArgs, err := DecodeArgs(argArr)
+	if err != nil {
+		return err
+	}
+
 	logger.Infof("Upgrading chaincode [%s] from path [%s] on channel [%s] with args [%s] and CC policy [%s] and collectionPolicy [%s] to the following peers: [%s]", ccID, ccPath, channelID, args, ccPolicy, collectionNames, peersAsString(sdkPeers))
 
 	_, err = resMgmtClient.UpgradeCC(
@@ -914,7 +1497,7 @@ func (d *CommonSteps) upgradeChaincodeWithOpts(ccType, ccID, ccVersion, ccPath,
 			Name:       ccID,
 			Path:       ccPath,
 			Version:    ccVersion,
-			Args:       GetByteArgs(strings.Split(args, ",")),
+			Args:       ccArgs,
 			Policy:     chaincodePolicy,
 			CollConfig: collConfig,
 		},
@@ -939,7 +1522,7 @@ func (d *CommonSteps) deployChaincodeToOrg(ccType, ccID, ccPath, orgIDs, channel
 	}
 	chaincodePolicy, err := d.newChaincodePolicy(ccPolicy, channelID)
 	if err != nil {
-		return fmt.Errorf("error creating endirsement policy: %s", err)
+		return errors.WithMessage(err, "error creating endirsement policy")
 	}
 
 	var sdkPeers []fabApi.Peer
@@ -956,7 +1539,7 @@ func (d *CommonSteps) deployChaincodeToOrg(ccType, ccID, ccPath, orgIDs, channel
 		resourceMgmt := d.BDDContext.ResMgmtClient(orgID, ADMIN)
 		isInstalled, err = IsChaincodeInstalled(resourceMgmt, sdkPeer, ccID)
 		if err != nil {
-			return fmt.Errorf("Error querying installed chaincodes: %s", err)
+			return errors.WithMessage(err, "Error querying installed chaincodes")
 		}
 
 		if !isInstalled {
@@ -970,14 +1553,22 @@ func (d *CommonSteps) deployChaincodeToOrg(ccType, ccID, ccPath, orgIDs, channel
 			installRqst := resmgmt.InstallCCRequest{Name: ccID, Path: ccPath, Version: "v1", Package: ccPkg}
 			_, err = resMgmtClient.InstallCC(installRqst, resmgmt.WithRetry(retry.DefaultResMgmtOpts))
 			if err != nil {
-				return fmt.Errorf("SendInstallProposal return error: %s", err)
+				return errors.WithMessage(err, "SendInstallProposal return error")
 			}
 		}
 
 		sdkPeers = append(sdkPeers, sdkPeer)
 	}
 
-	argsArray := strings.Split(args, ",")
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return err
+	}
+
+	ccArgs, err := DecodeArgs(argArr)
+	if err != nil {
+		return err
+	}
 
 	var collConfig []*common.CollectionConfig
 	if collectionNames != "" {
@@ -994,7 +1585,7 @@ func (d *CommonSteps) deployChaincodeToOrg(ccType, ccID, ccPath, orgIDs, channel
 
 	resMgmtClient := d.BDDContext.ResMgmtClient(orgID, ADMIN)
 
-	instantiateRqst := resmgmt.InstantiateCCRequest{Name: ccID, Path: ccPath, Version: "v1", Args: GetByteArgs(argsArray), Policy: chaincodePolicy,
+	instantiateRqst := resmgmt.InstantiateCCRequest{Name: ccID, Path: ccPath, Version: "v1", Args: ccArgs, Policy: chaincodePolicy,
 		CollConfig: collConfig}
 
 	_, err = resMgmtClient.InstantiateCC(
@@ -1088,59 +1679,6 @@ func (d *CommonSteps) DefineCollectionConfig(id, name, policy string, requiredPe
 	)
 }
 
-// ClearResponse clears the query response
-func ClearResponse() {
-	queryValue = ""
-}
-
-// GetResponse returns the most recent query response
-func GetResponse() string {
-	return queryValue
-}
-
-// SetResponse sets the query response
-func SetResponse(response string) {
-	queryValue = response
-}
-
-// SetVar sets the value for the given variable
-func SetVar(varName, value string) {
-	vars[varName] = value
-}
-
-// GetVar gets the value for the given variable
-// Returns true if the variable exists; false otherwise
-func GetVar(varName string) (string, bool) {
-	value, ok := vars[varName]
-	return value, ok
-}
-
-// ResolveAllVars returns a slice of strings from the given comma-separated string.
-// Each string is resolved for variables.
-// Resolve resolves all variables within the given arg
-//
-// Example 1: Simple variable
-// 	Given:
-// 		vars = {
-// 			"var1": "value1",
-// 			"var2": "value2",
-// 			}
-//	Then:
-//		"${var1}" = "value1"
-//		"X_${var1}_${var2} = "X_value1_value2
-//
-// Example 2: Array variable
-// 	Given:
-// 		vars = {
-// 			"arr1": "value1,value2,value3",
-// 			}
-//	Then:
-//		"${arr1[0]_arr1[1]_arr1[2]}" = "value1_value2_value3"
-//
-func ResolveAllVars(args string) ([]string, error) {
-	return ResolveAll(vars, strings.Split(args, ","))
-}
-
 func newPrivateCollectionConfig(collName string, requiredPeerCount, maxPeerCount int32, blocksToLive uint64, policy *common.SignaturePolicyEnvelope) *common.CollectionConfig {
 	return &common.CollectionConfig{
 		Payload: &common.CollectionConfig_StaticCollectionConfig{
@@ -1166,16 +1704,14 @@ func NewChaincodePolicy(bddCtx *BDDContext, ccPolicy, channelID string) (*fabric
 		return newPolicy(ccPolicy)
 	}
 
-	netwkConfig := bddCtx.clientConfig.NetworkConfig()
-
 	// Default policy is 'signed by any member' for all known orgs
 	var mspIDs []string
 	for _, orgID := range bddCtx.OrgsByChannel(channelID) {
-		orgConfig, ok := netwkConfig.Organizations[strings.ToLower(orgID)]
-		if !ok {
-			return nil, errors.Errorf("org config not found for org ID %s", orgID)
+		mspID, err := bddCtx.OrgMSPID(orgID)
+		if err != nil {
+			return nil, err
 		}
-		mspIDs = append(mspIDs, orgConfig.MSPID)
+		mspIDs = append(mspIDs, mspID)
 	}
 	logger.Infof("Returning SignedByAnyMember policy for MSPs %s", mspIDs)
 	return cauthdsl.SignedByAnyMember(mspIDs), nil
@@ -1183,42 +1719,151 @@ func NewChaincodePolicy(bddCtx *BDDContext, ccPolicy, channelID string) (*fabric
 
 // RegisterSteps register steps
 func (d *CommonSteps) RegisterSteps(s *godog.Suite) {
+	s.BeforeFeature(d.BDDContext.BeforeFeature)
 	s.BeforeScenario(d.BDDContext.BeforeScenario)
 	s.AfterScenario(d.BDDContext.AfterScenario)
 
-	s.Step(`^the channel "([^"]*)" is created and all peers have joined$`, d.createChannelAndJoinAllPeers)
-	s.Step(`^the channel "([^"]*)" is created and all peers from org "([^"]*)" have joined$`, d.createChannelAndJoinPeersFromOrg)
+	d.stepWithExpectedError(s, `^the channel "([^"]*)" is created and all peers have joined$`, d.createChannelAndJoinAllPeers)
+	d.stepWithExpectedError(s, `^the channel "([^"]*)" is created and all peers from org "([^"]*)" have joined$`, d.createChannelAndJoinPeersFromOrg)
 	s.Step(`^we wait (\d+) seconds$`, d.wait)
-	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.queryCConOrg)
-	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on a single peer in the "([^"]*)" org on the "([^"]*)" channel$`, d.queryCConSinglePeerInOrg)
-	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.queryCConTargetPeers)
-	s.Step(`^client queries system chaincode "([^"]*)" with args "([^"]*)" on org "([^"]*)" peer on the "([^"]*)" channel$`, d.querySystemCC)
-	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel$`, d.queryCC)
-	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel then the error response should contain "([^"]*)"$`, d.queryCCWithError)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.queryCConOrg)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCConOrgAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on a single peer in the "([^"]*)" org on the "([^"]*)" channel$`, d.queryCConSinglePeerInOrg)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on a single peer in the "([^"]*)" org on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCConSinglePeerInOrgAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.queryCConTargetPeers)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCConTargetPeersAsUser)
+	d.stepWithExpectedError(s, `^client queries system chaincode "([^"]*)" with args "([^"]*)" on org "([^"]*)" peer on the "([^"]*)" channel$`, d.querySystemCC)
+	d.stepWithExpectedError(s, `^client queries system chaincode "([^"]*)" with args "([^"]*)" on org "([^"]*)" peer on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.querySystemCCAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel$`, d.queryCC)
 	s.Step(`^response from "([^"]*)" to client contains value "([^"]*)"$`, d.containsInQueryValue)
 	s.Step(`^response from "([^"]*)" to client equal value "([^"]*)"$`, d.equalQueryValue)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" version "([^"]*)" is installed from path "([^"]*)" to all peers$`, d.installChaincodeToAllPeersWithVersion)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers$`, d.installChaincodeToAllPeers)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers in the "([^"]*)" org$`, d.installChaincodeToOrg)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers except "([^"]*)"$`, d.installChaincodeToAllPeersExcept)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.instantiateChaincodeOnOrg)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.instantiateChaincode)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is upgraded with version "([^"]*)" from path "([^"]*)" on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.upgradeChaincode)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is upgraded with version "([^"]*)" from path "([^"]*)" on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)" then the error response should contain "([^"]*)"$`, d.upgradeChaincodeWithError)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is deployed from path "([^"]*)" to all peers in the "([^"]*)" org on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.deployChaincodeToOrg)
-	s.Step(`^"([^"]*)" chaincode "([^"]*)" is deployed from path "([^"]*)" to all peers on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.deployChaincode)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" version "([^"]*)" is installed from path "([^"]*)" to all peers$`, d.installChaincodeToAllPeersWithVersion)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" version "([^"]*)" is installed from path "([^"]*)" to all peers as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodeToAllPeersWithVersionAsUser)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers$`, d.installChaincodeToAllPeers)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodeToAllPeersAsUser)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers in the "([^"]*)" org$`, d.installChaincodeToOrg)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodeToOrgAsUser)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers except "([^"]*)"$`, d.installChaincodeToAllPeersExcept)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is installed from path "([^"]*)" to all peers except "([^"]*)" as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodeToAllPeersExceptAsUser)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.instantiateChaincodeOnOrg)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.instantiateChaincode)
+	d.stepWithArgsAndExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)" with args:$`, d.instantiateChaincodeWithDocString)
+	d.stepWithArgsAndExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is instantiated from path "([^"]*)" on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)" with args in the table:$`, d.instantiateChaincodeWithDataTable)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is upgraded with version "([^"]*)" from path "([^"]*)" on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.upgradeChaincode)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is deployed from path "([^"]*)" to all peers in the "([^"]*)" org on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.deployChaincodeToOrg)
+	d.stepWithExpectedError(s, `^"([^"]*)" chaincode "([^"]*)" is deployed from path "([^"]*)" to all peers on the "([^"]*)" channel with args "([^"]*)" with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.deployChaincode)
 	s.Step(`^chaincode "([^"]*)" is warmed up on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.warmUpCConOrg)
 	s.Step(`^chaincode "([^"]*)" is warmed up on all peers on the "([^"]*)" channel$`, d.warmUpCC)
-	s.Step(`^client invokes chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.InvokeCConOrg)
-	s.Step(`^client invokes chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel$`, d.InvokeCC)
-	s.Step(`^client invokes chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.invokeCConTargetPeers)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.InvokeCConOrg)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on all peers in the "([^"]*)" org on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCConOrgAsUser)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel$`, d.InvokeCC)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCCAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCCAsUser)
+	s.Step(`^user "([^"]*)" of org "([^"]*)" uses the crypto material of user "([^"]*)"$`, d.registerUser)
+	s.Step(`^user "([^"]*)" is registered with the CA of org "([^"]*)" as type "([^"]*)" with affiliation "([^"]*)"$`, d.registerUserWithCA)
+	s.Step(`^user "([^"]*)" is registered with the CA of org "([^"]*)" as type "([^"]*)" with affiliation "([^"]*)" and attributes "([^"]*)"$`, d.registerUserWithCAAndAttributes)
+	s.Step(`^user "([^"]*)" is enrolled with the CA of org "([^"]*)"$`, d.enrollUserWithCA)
+	s.Step(`^user "([^"]*)" is enrolled with the CA of org "([^"]*)" requesting attributes "([^"]*)"$`, d.enrollUserWithCAAndAttributes)
+	s.Step(`^user "([^"]*)" is enrolled with the CA of org "([^"]*)" using secret "([^"]*)"$`, d.enrollUserWithCAAndSecret)
+	s.Step(`^user "([^"]*)" is re-enrolled with the CA of org "([^"]*)"$`, d.reenrollUserWithCA)
+	s.Step(`^user "([^"]*)" is revoked by the CA of org "([^"]*)"$`, d.revokeUserWithCA)
+	s.Step(`^user "([^"]*)" of org "([^"]*)" is enrolled with attributes "([^"]*)"$`, d.enrollUserWithAttributes)
+	s.Step(`^client invokes chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" then access is granted$`, d.invokeCCAsUserIsGranted)
+	s.Step(`^client invokes chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" then access is denied$`, d.invokeCCAsUserIsDenied)
+	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" then access is granted$`, d.queryCCAsUserIsGranted)
+	s.Step(`^client queries chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" then access is denied$`, d.queryCCAsUserIsDenied)
+	d.stepWithArgsAndExpectedError(s, `^client invokes chaincode "([^"]*)" on the "([^"]*)" channel with args:$`, d.invokeCCWithDocString)
+	d.stepWithArgsAndExpectedError(s, `^client invokes chaincode "([^"]*)" on the "([^"]*)" channel with args in the table:$`, d.invokeCCWithDataTable)
+	d.stepWithArgsAndExpectedError(s, `^client queries chaincode "([^"]*)" on the "([^"]*)" channel with args:$`, d.queryCCWithDocString)
+	d.stepWithArgsAndExpectedError(s, `^client queries chaincode "([^"]*)" on the "([^"]*)" channel with args in the table:$`, d.queryCCWithDataTable)
+	d.stepWithArgsAndExpectedError(s, `^client invokes chaincode "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" with args:$`, d.invokeCCWithDocStringAsUser)
+	d.stepWithArgsAndExpectedError(s, `^client invokes chaincode "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" with args in the table:$`, d.invokeCCWithDataTableAsUser)
+	d.stepWithArgsAndExpectedError(s, `^client queries chaincode "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" with args:$`, d.queryCCWithDocStringAsUser)
+	d.stepWithArgsAndExpectedError(s, `^client queries chaincode "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)" with args in the table:$`, d.queryCCWithDataTableAsUser)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.invokeCConTargetPeers)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCConTargetPeersAsUser)
+	s.Step(`^transient map "([^"]*)" is defined as:$`, d.defineTransientMap)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the "([^"]*)" channel$`, d.invokeCCWithTransientMap)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCCWithTransientMapAsUser)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.invokeCCWithTransientMapOnPeers)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCCWithTransientMapOnPeersAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the "([^"]*)" channel$`, d.queryCCWithTransientMap)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCCWithTransientMapAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel$`, d.queryCCWithTransientMapOnPeers)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on peers "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCCWithTransientMapOnPeersAsUser)
+	s.Step(`^"([^"]*)" chaincode is packaged with label "([^"]*)" from path "([^"]*)"$`, d.packageChaincode)
+	d.stepWithExpectedError(s, `^chaincode package "([^"]*)" is installed to all peers$`, d.installChaincodePackageToAllPeers)
+	d.stepWithExpectedError(s, `^chaincode package "([^"]*)" is installed to all peers as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodePackageToAllPeersAsUser)
+	d.stepWithExpectedError(s, `^chaincode package "([^"]*)" is installed to all peers in the "([^"]*)" org$`, d.installChaincodePackageToOrg)
+	d.stepWithExpectedError(s, `^chaincode package "([^"]*)" is installed to all peers in the "([^"]*)" org as user "([^"]*)" of org "([^"]*)"$`, d.installChaincodePackageToOrgAsUser)
+	d.stepWithExpectedError(s, `^chaincode "([^"]*)" version "([^"]*)" sequence (\d+) with package "([^"]*)" is approved by the "([^"]*)" org on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.approveChaincodeDefinition)
+	d.stepWithExpectedError(s, `^chaincode "([^"]*)" version "([^"]*)" sequence (\d+) with package "([^"]*)" is approved by the "([^"]*)" org on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)" and init required$`, d.approveChaincodeDefinitionWithInit)
+	s.Step(`^the commit readiness of chaincode "([^"]*)" version "([^"]*)" sequence (\d+) is checked on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.checkCommitReadiness)
+	s.Step(`^the commit readiness of chaincode "([^"]*)" version "([^"]*)" sequence (\d+) is checked on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)" and init required$`, d.checkCommitReadinessWithInit)
+	d.stepWithExpectedError(s, `^chaincode "([^"]*)" version "([^"]*)" sequence (\d+) is committed by the "([^"]*)" org on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)"$`, d.commitChaincodeDefinition)
+	d.stepWithExpectedError(s, `^chaincode "([^"]*)" version "([^"]*)" sequence (\d+) is committed by the "([^"]*)" org on the "([^"]*)" channel with endorsement policy "([^"]*)" with collection policy "([^"]*)" and init required$`, d.commitChaincodeDefinitionWithInit)
 	s.Step(`^collection config "([^"]*)" is defined for collection "([^"]*)" as policy="([^"]*)", requiredPeerCount=(\d+), maxPeerCount=(\d+), and blocksToLive=(\d+)$`, d.defineCollectionConfig)
+	s.Step(`^collection configs are loaded from file "([^"]*)"$`, d.loadCollectionConfigs)
+	s.Step(`^private data returned by chaincode "([^"]*)" with args "([^"]*)" is present on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.privateDataIsPresentOnOrg)
+	s.Step(`^private data returned by chaincode "([^"]*)" with args "([^"]*)" is absent on all peers in the "([^"]*)" org on the "([^"]*)" channel$`, d.privateDataIsAbsentOnOrg)
+	s.Step(`^private data returned by chaincode "([^"]*)" with args "([^"]*)" is present only on members of collection config "([^"]*)" on the "([^"]*)" channel$`, d.privateDataIsPresentOnlyOnMembers)
+	s.Step(`^private data returned by chaincode "([^"]*)" with args "([^"]*)" in collection config "([^"]*)" is purged after blocksToLive blocks are generated by chaincode "([^"]*)" with args "([^"]*)" on the "([^"]*)" channel$`, d.privateDataIsPurged)
+	s.Step(`^the private data hash returned by chaincode "([^"]*)" with args "([^"]*)" is present on all peers on the "([^"]*)" channel$`, d.privateDataHashIsPresentOnAllPeers)
+	s.Step(`^the private data hash returned by chaincode "([^"]*)" with args "([^"]*)" is the hash of value "([^"]*)" on all peers on the "([^"]*)" channel$`, d.privateDataHashOfValueIsPresentOnAllPeers)
+	s.Step(`^the implicit collection of org "([^"]*)" is saved to variable "([^"]*)"$`, d.setVariableFromImplicitCollection)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the implicit collection of org "([^"]*)" on the "([^"]*)" channel$`, d.invokeCCWithTransientMapOnImplicitCollection)
+	d.stepWithExpectedError(s, `^client invokes chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the implicit collection of org "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.invokeCCWithTransientMapOnImplicitCollectionAsUser)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the implicit collection of org "([^"]*)" on the "([^"]*)" channel$`, d.queryCCWithTransientMapOnImplicitCollection)
+	d.stepWithExpectedError(s, `^client queries chaincode "([^"]*)" with args "([^"]*)" and transient map "([^"]*)" on the implicit collection of org "([^"]*)" on the "([^"]*)" channel as user "([^"]*)" of org "([^"]*)"$`, d.queryCCWithTransientMapOnImplicitCollectionAsUser)
+	s.Step(`^private data returned by chaincode "([^"]*)" with args "([^"]*)" is present only on members of the implicit collection of org "([^"]*)" on the "([^"]*)" channel$`, d.privateDataIsPresentOnlyOnImplicitCollectionMembers)
+	s.Step(`^the private data hash returned by chaincode "([^"]*)" with args "([^"]*)" for the implicit collection of org "([^"]*)" is present on all peers on the "([^"]*)" channel$`, d.privateDataHashIsPresentOnAllPeersForImplicitCollection)
+	s.Step(`^the private data hash returned by chaincode "([^"]*)" with args "([^"]*)" for the implicit collection of org "([^"]*)" is the hash of value "([^"]*)" on all peers on the "([^"]*)" channel$`, d.privateDataHashOfValueIsPresentOnAllPeersForImplicitCollection)
 	s.Step(`^block (\d+) from the "([^"]*)" channel is displayed$`, d.displayBlockFromChannel)
 	s.Step(`^the last (\d+) blocks from the "([^"]*)" channel are displayed$`, d.displayBlocksFromChannel)
 	s.Step(`^the last block from the "([^"]*)" channel is displayed$`, d.displayLastBlockFromChannel)
+	s.Step(`^block (\d+) from the "([^"]*)" channel is retrieved$`, d.retrieveBlockFromChannel)
+	s.Step(`^the last block from the "([^"]*)" channel is retrieved$`, d.retrieveLastBlockFromChannel)
+	s.Step(`^block (\d+) from the "([^"]*)" channel contains a transaction for chaincode "([^"]*)"$`, d.blockContainsTxForChaincode)
+	s.Step(`^the last block from the "([^"]*)" channel contains a transaction for chaincode "([^"]*)"$`, d.lastBlockContainsTxForChaincode)
+	s.Step(`^block (\d+) from the "([^"]*)" channel has (\d+) transactions$`, d.blockHasNumTransactions)
+	s.Step(`^the last transaction on the "([^"]*)" channel was validated with code "([^"]*)"$`, d.lastTxValidatedWithCode)
+	s.Step(`^a block listener is started on the "([^"]*)" channel$`, d.startBlockListener)
+	s.Step(`^a filtered block listener is started on the "([^"]*)" channel$`, d.startFilteredBlockListener)
+	s.Step(`^all peers on the "([^"]*)" channel reach block height at least (\d+) within (\d+) seconds$`, d.allPeersReachBlockHeight)
+	s.Step(`^all peers on the "([^"]*)" channel reach the same block height within (\d+) seconds$`, d.allPeersReachSameBlockHeight)
+	s.Step(`^peer "([^"]*)" has delivered block (\d+) on the "([^"]*)" channel$`, d.peerDeliveredBlock)
+	s.Step(`^all peers on the "([^"]*)" channel have the same ledger height and block hash$`, d.ledgersAreConsistent)
+	s.Step(`^all peers on the "([^"]*)" channel have the same ledger height and block hash within (\d+) seconds$`, d.ledgersAreConsistentWithin)
+	s.Step(`^client registers for chaincode events from chaincode "([^"]*)" matching "([^"]*)" on the "([^"]*)" channel$`, d.registerForChaincodeEvents)
+	s.Step(`^an event named "([^"]*)" is received within (\d+) seconds$`, d.chaincodeEventReceived)
+	s.Step(`^an event named "([^"]*)" with payload "([^"]*)" is received within (\d+) seconds$`, d.chaincodeEventWithPayloadReceived)
+	s.Step(`^the payload of the event named "([^"]*)" is saved to variable "([^"]*)"$`, d.setVariableFromChaincodeEvent)
+	s.Step(`^the error should have status group "([^"]*)" and code "([^"]*)"$`, d.lastErrorHasStatus)
+	s.Step(`^the error response should contain "([^"]*)"$`, d.lastErrorContains)
+	s.Step(`^the error response matches "([^"]*)"$`, d.lastErrorMatches)
+	s.Step(`^the error response is saved to variable "([^"]*)"$`, d.setVariableFromLastError)
+	s.Step(`^the chaincode error message is saved to variable "([^"]*)"$`, d.setVariableFromChaincodeErrorMessage)
+	s.Step(`^the JSON path "([^"]*)" of the chaincode error message equals "([^"]*)"$`, d.jsonPathOfChaincodeErrorMessageEquals)
 	s.Step(`^the response is saved to variable "([^"]*)"$`, d.setVariableFromCCResponse)
+	s.Step(`^the response is saved to variable "([^"]*)" as (base64|hex)$`, d.setVariableFromCCResponseWithEncoding)
 	s.Step(`^variable "([^"]*)" is assigned the JSON value '([^']*)'$`, d.setJSONVariable)
+	s.Step(`^variable "([^"]*)" is assigned the value "([^"]*)"$`, d.setVariable)
+	s.Step(`^feature variable "([^"]*)" is assigned the value "([^"]*)"$`, d.setFeatureVariable)
+	s.Step(`^suite variable "([^"]*)" is assigned the value "([^"]*)"$`, d.setSuiteVariable)
 	s.Step(`^the JSON path "([^"]*)" of the response equals "([^"]*)"$`, d.jsonPathOfCCResponseEquals)
 	s.Step(`^the JSON path "([^"]*)" of the response has (\d+) items$`, d.jsonPathOfCCHasNumItems)
 	s.Step(`^the JSON path "([^"]*)" of the response contains "([^"]*)"$`, d.jsonPathOfCCResponseContains)
+	s.Step(`^the JSON path "([^"]*)" of the response matches "([^"]*)"$`, d.jsonPathOfCCResponseMatches)
+	s.Step(`^the JSON path "([^"]*)" of the response equals JSON '([^']*)'$`, d.jsonPathOfCCResponseEqualsJSON)
+	s.Step(`^the JSON path "([^"]*)" of the response is greater than "([^"]*)"$`, d.jsonPathOfCCResponseIsGreaterThan)
+	s.Step(`^the JSON path "([^"]*)" of the response is less than "([^"]*)"$`, d.jsonPathOfCCResponseIsLessThan)
+	s.Step(`^the JSON path "([^"]*)" of the response is between "([^"]*)" and "([^"]*)"$`, d.jsonPathOfCCResponseIsBetween)
+	s.Step(`^the JSON path "([^"]*)" of the response is (true|false|\$\{[^}]+\})$`, d.jsonPathOfCCResponseIsBoolean)
+	s.Step(`^the JSON path "([^"]*)" of the response is null$`, d.jsonPathOfCCResponseIsNull)
+	s.Step(`^the JSON path "([^"]*)" of the response is of type "(string|number|boolean|null|object|array)"$`, d.jsonPathOfCCResponseIsOfType)
+	s.Step(`^the JSON path "([^"]*)" of the response exists$`, d.jsonPathOfCCResponseExists)
+	s.Step(`^the JSON path "([^"]*)" of the response does not exist$`, d.jsonPathOfCCResponseDoesNotExist)
+	s.Step(`^the response matches "([^"]*)"$`, d.responseMatches)
+	s.Step(`^the response equals JSON '([^']*)'$`, d.responseEqualsJSON)
+	s.Step(`^the response equals JSON:$`, d.responseEqualsJSONDocString)
 }
@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	reqContext "context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeCCWithArgsAsUser(t *testing.T) {
+	b := newTestBDDContext()
+	b.orgs = []string{"org1"}
+	d := &CommonSteps{BDDContext: b}
+
+	peer := newRecordingPeer("peer0.org1.example.com")
+	b.orgChannelClients[orgUserChannel{orgUser: orgUser{org: "org1", user: "reader"}, channelID: "mychannel"}] = newTestChannelClient(t, "mychannel", peer)
+
+	transientData := map[string][]byte{"key1": []byte("value1")}
+	_, err := d.InvokeCCWithArgsAsUser("mycc", "mychannel", nil, []string{"put", "k1", "v1"}, transientData, "", "reader")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proposal rejected")
+
+	require.NotNil(t, peer.invocation, "expecting the proposal to be sent to the peer")
+	assert.Equal(t, "mycc", peer.invocation.ChaincodeSpec.ChaincodeId.Name)
+	assert.Equal(t, [][]byte{[]byte("put"), []byte("k1"), []byte("v1")}, peer.invocation.ChaincodeSpec.Input.Args)
+	assert.Equal(t, transientData, peer.transientMap)
+}
+
+// recordingPeer is a mock peer which records the chaincode invocation and transient map of the proposal
+// that it receives and then rejects the proposal
+type recordingPeer struct {
+	*fabmocks.MockPeer
+	invocation   *pb.ChaincodeInvocationSpec
+	transientMap map[string][]byte
+}
+
+func newRecordingPeer(name string) *recordingPeer {
+	return &recordingPeer{MockPeer: fabmocks.NewMockPeer(name, "grpcs://"+name+":7051")}
+}
+
+func (p *recordingPeer) ProcessTransactionProposal(_ reqContext.Context, request fabApi.ProcessProposalRequest) (*fabApi.TransactionProposalResponse, error) {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(request.SignedProposal.ProposalBytes, proposal); err != nil {
+		return nil, err
+	}
+
+	payload := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(proposal.Payload, payload); err != nil {
+		return nil, err
+	}
+
+	invocation := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.Input, invocation); err != nil {
+		return nil, err
+	}
+
+	p.invocation = invocation
+	p.transientMap = payload.TransientMap
+
+	return nil, errors.New("proposal rejected")
+}
+
+// newTestChannelClient returns a channel client which sends all proposals to the given peers
+func newTestChannelClient(t *testing.T, channelID string, peers ...fabApi.Peer) *channel.Client {
+	ctx := fabmocks.NewMockContext(mockmsp.NewMockSigningIdentity("user", "Org1MSP"))
+
+	chProvider, err := fabmocks.NewMockChannelProvider(ctx)
+	require.NoError(t, err)
+
+	chService, err := chProvider.ChannelService(ctx, channelID)
+	require.NoError(t, err)
+
+	mockChService := chService.(*fabmocks.MockChannelService)
+	mockChService.SetTransactor(&clientmocks.MockTransactor{Ctx: ctx, ChannelID: channelID})
+	mockChService.SetSelection(clientmocks.NewMockSelectionService(nil, peers...))
+	mockChService.SetDiscovery(clientmocks.NewMockDiscoveryService(nil, peers...))
+	ctx.MockProviderContext.ChannelProvider().(*fabmocks.MockChannelProvider).SetCustomChannelService(chService)
+
+	chClient, err := channel.New(func() (contextApi.Channel, error) {
+		return contextImpl.NewChannel(func() (contextApi.Client, error) { return ctx, nil }, channelID)
+	})
+	require.NoError(t, err)
+
+	return chClient
+}
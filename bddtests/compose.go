@@ -11,11 +11,18 @@ import (
 	"io/ioutil"
 	"os/exec"
 	"strings"
+	"sync"
 
 	docker "github.com/fsouza/go-dockerclient"
 )
 
-const dockerComposeCommand = "docker-compose"
+const (
+	dockerComposeCommand = "docker-compose"
+	composeServiceLabel  = "com.docker.compose.service"
+
+	// containerStopTimeout is the number of seconds to wait for a container to stop before it is killed
+	containerStopTimeout = 10
+)
 
 // Composition represents a docker-compose execution and management
 type Composition struct {
@@ -27,6 +34,11 @@ type Composition struct {
 	composeFilesYaml string
 	projectName      string
 	dockerHelper     DockerHelper
+
+	faultMutex             sync.Mutex
+	disconnectedContainers map[string][]networkAttachment
+	partitionNetworks      []string
+	networkFaults          map[string]NetworkFault
 }
 
 // NewDockerCompose create a new Composition specifying the project name (for isolation) and the compose files.
@@ -36,13 +48,19 @@ func NewDockerCompose(projectName, composeFilesYaml string, dir string) (*Compos
 	}
 
 	endpoint := "unix:///var/run/docker.sock"
-	composition := &Composition{composeFilesYaml: composeFilesYaml, projectName: projectName, dir: dir}
+	composition := &Composition{
+		composeFilesYaml:       composeFilesYaml,
+		projectName:            projectName,
+		dir:                    dir,
+		disconnectedContainers: make(map[string][]networkAttachment),
+		networkFaults:          make(map[string]NetworkFault),
+	}
 
 	var err error
 	if composition.dockerClient, err = docker.NewClient(endpoint); err != nil {
 		return nil, errRetFunc(err)
 	}
-	if composition.dockerHelper, err = NewDockerCmdlineHelper(); err != nil {
+	if composition.dockerHelper, err = NewDockerAPIHelper(composition.dockerClient); err != nil {
 		return nil, errRetFunc(err)
 	}
 	return composition, nil
@@ -94,7 +112,7 @@ func (c *Composition) refreshContainerList() (err error) {
 	//	return fmt.Errorf("Error refreshing container list for project '%s':  %s", c.projectName, err)
 	//}
 	for _, apiContainer := range allAPIContainers {
-		if composeService, ok := apiContainer.Labels["com.docker.compose.service"]; ok == true {
+		if composeService, ok := apiContainer.Labels[composeServiceLabel]; ok == true {
 			fmt.Println(fmt.Sprintf("Container name:  %s, composeService: %s, IPAddress: %s", apiContainer.Names[0], composeService, apiContainer.Networks.Networks["bridge"].IPAddress))
 		}
 	}
@@ -155,7 +173,7 @@ func (c *Composition) GenerateLogs() error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile("docker-compose.log", outputBytes, 775)
+	err = ioutil.WriteFile("docker-compose.log", outputBytes, 0644)
 	return err
 }
 
@@ -166,14 +184,25 @@ func (c *Composition) parseComposition() (err error) {
 }
 
 // GetAPIContainerForComposeService return the docker.APIContainers with the supplied composeService name.
+// If no container is labelled with the compose service then the container with the given name is returned.
 func (c *Composition) GetAPIContainerForComposeService(composeService string) (apiContainer *docker.APIContainers, err error) {
+	if err = c.refreshContainerList(); err != nil {
+		return nil, err
+	}
 	for _, apiContainer := range c.apiContainers {
-		if currComposeService, ok := apiContainer.Labels["com.docker.compose.service"]; ok == true {
+		if currComposeService, ok := apiContainer.Labels[composeServiceLabel]; ok == true {
 			if currComposeService == composeService {
 				return &apiContainer, nil
 			}
 		}
 	}
+	for _, apiContainer := range c.apiContainers {
+		for _, name := range apiContainer.Names {
+			if strings.TrimPrefix(name, "/") == composeService {
+				return &apiContainer, nil
+			}
+		}
+	}
 	return nil, fmt.Errorf("Could not find container with compose service '%s'", composeService)
 }
 
@@ -186,6 +215,69 @@ func (c *Composition) GetIPAddressForComposeService(composeService string) (ipAd
 	if apiContainer, err = c.GetAPIContainerForComposeService(composeService); err != nil {
 		return "", errRetFunc()
 	}
-	// Now get the IPAddress
-	return apiContainer.Networks.Networks["bridge"].IPAddress, nil
+	if ipAddress, err = c.dockerHelper.GetIPAddress(apiContainer.ID); err != nil {
+		return "", errRetFunc()
+	}
+	return ipAddress, nil
+}
+
+// StartContainer starts the container for the given compose service. No error is returned if the
+// container is already running.
+func (c *Composition) StartContainer(composeService string) error {
+	return c.containerCommand("start", composeService, func(id string) error {
+		err := c.dockerClient.StartContainer(id, nil)
+		if _, ok := err.(*docker.ContainerAlreadyRunning); ok {
+			logger.Infof("Container for compose service '%s' is already running", composeService)
+			return nil
+		}
+		return err
+	})
+}
+
+// StopContainer stops the container for the given compose service. The container is killed if it
+// doesn't stop within the stop timeout. No error is returned if the container is not running.
+func (c *Composition) StopContainer(composeService string) error {
+	return c.containerCommand("stop", composeService, func(id string) error {
+		err := c.dockerClient.StopContainer(id, containerStopTimeout)
+		if _, ok := err.(*docker.ContainerNotRunning); ok {
+			logger.Infof("Container for compose service '%s' is not running", composeService)
+			return nil
+		}
+		return err
+	})
+}
+
+// RestartContainer restarts the container for the given compose service
+func (c *Composition) RestartContainer(composeService string) error {
+	return c.containerCommand("restart", composeService, func(id string) error {
+		return c.dockerClient.RestartContainer(id, containerStopTimeout)
+	})
+}
+
+// PauseContainer pauses the container for the given compose service
+func (c *Composition) PauseContainer(composeService string) error {
+	return c.containerCommand("pause", composeService, c.dockerClient.PauseContainer)
+}
+
+// UnpauseContainer un-pauses the container for the given compose service
+func (c *Composition) UnpauseContainer(composeService string) error {
+	return c.containerCommand("unpause", composeService, c.dockerClient.UnpauseContainer)
+}
+
+// KillContainer sends a SIGKILL to the container for the given compose service
+func (c *Composition) KillContainer(composeService string) error {
+	return c.containerCommand("kill", composeService, func(id string) error {
+		return c.dockerClient.KillContainer(docker.KillContainerOptions{ID: id})
+	})
+}
+
+func (c *Composition) containerCommand(cmd, composeService string, fn func(containerID string) error) error {
+	apiContainer, err := c.GetAPIContainerForComposeService(composeService)
+	if err != nil {
+		return fmt.Errorf("Error issuing '%s' to container for compose service '%s':  %s", cmd, composeService, err)
+	}
+	if err := fn(apiContainer.ID); err != nil {
+		return fmt.Errorf("Error issuing '%s' to container for compose service '%s':  %s", cmd, composeService, err)
+	}
+	return nil
 }
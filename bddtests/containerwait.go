@@ -0,0 +1,183 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	containerWaitPollInterval = 500 * time.Millisecond
+	containerDialTimeout      = time.Second
+)
+
+// WaitForHealthy waits until the Docker health check of the container for the given compose service
+// reports that the container is healthy. An error is returned immediately if the container has no health check.
+func (c *Composition) WaitForHealthy(composeService string, timeout time.Duration) error {
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	return c.waitFor(composeService, "to be healthy", timeout, func() (bool, error) {
+		container, err := c.dockerClient.InspectContainer(containerID)
+		if err != nil {
+			return false, err
+		}
+
+		switch container.State.Health.Status {
+		case "healthy":
+			return true, nil
+		case "":
+			return false, fmt.Errorf("Container for compose service '%s' does not have a health check", composeService)
+		default:
+			logger.Debugf("Container for compose service '%s' has health status '%s'", composeService, container.State.Health.Status)
+			return false, nil
+		}
+	})
+}
+
+// WaitForPort waits until the given TCP port of the container for the given compose service accepts connections.
+// If the port is published then the connection is made to the published port on the host, otherwise the
+// connection is made directly to the container's IP address.
+func (c *Composition) WaitForPort(composeService string, port int, timeout time.Duration) error {
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	return c.waitFor(composeService, fmt.Sprintf("to accept connections on port %d", port), timeout, func() (bool, error) {
+		container, err := c.dockerClient.InspectContainer(containerID)
+		if err != nil {
+			return false, err
+		}
+
+		address := containerPortAddress(container, port)
+		if address == "" {
+			logger.Debugf("No address available yet for port %d of compose service '%s'", port, composeService)
+			return false, nil
+		}
+
+		conn, err := net.DialTimeout("tcp", address, containerDialTimeout)
+		if err != nil {
+			logger.Debugf("Port %d of compose service '%s' is not accepting connections at %s: %s", port, composeService, address, err)
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	})
+}
+
+// WaitForLogLine waits until a line matching the given regular expression appears in the output of the container
+// for the given compose service. Only output written since the container was last started is considered.
+func (c *Composition) WaitForLogLine(composeService, expr string, timeout time.Duration) (string, error) {
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("Invalid regular expression '%s':  %s", expr, err)
+	}
+
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return "", err
+	}
+
+	var matchingLine string
+	err = c.waitFor(composeService, fmt.Sprintf("to log a line matching '%s'", expr), timeout, func() (bool, error) {
+		container, err := c.dockerClient.InspectContainer(containerID)
+		if err != nil {
+			return false, err
+		}
+
+		logs, err := c.containerLogs(containerID, container.State.StartedAt)
+		if err != nil {
+			return false, err
+		}
+
+		for _, line := range strings.Split(logs, "\n") {
+			if regex.MatchString(line) {
+				matchingLine = line
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return matchingLine, err
+}
+
+// containerLogs returns the stdout and stderr of the given container written since the given time.
+// If since is zero then all output is returned.
+func (c *Composition) containerLogs(containerID string, since time.Time) (string, error) {
+	var output bytes.Buffer
+	opts := docker.LogsOptions{
+		Container:    containerID,
+		OutputStream: &output,
+		ErrorStream:  &output,
+		Stdout:       true,
+		Stderr:       true,
+		Timestamps:   true,
+	}
+	if !since.IsZero() {
+		// The API only has a granularity of seconds so the lines are also filtered by their timestamps below
+		opts.Since = since.Unix()
+	}
+
+	if err := c.dockerClient.Logs(opts); err != nil {
+		return "", fmt.Errorf("Error getting logs for container '%s':  %s", containerID, err)
+	}
+	return filterLogsSince(output.String(), since), nil
+}
+
+// waitFor polls the given condition until it returns true or the timeout expires. Polling stops if the
+// condition returns an error.
+func (c *Composition) waitFor(composeService, description string, timeout time.Duration, condition func() (bool, error)) error {
+	logger.Infof("Waiting up to %s for compose service '%s' %s", timeout, composeService, description)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if done {
+			logger.Infof("Compose service '%s' is ready", composeService)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for compose service '%s' %s", timeout, composeService, description)
+		}
+		time.Sleep(containerWaitPollInterval)
+	}
+}
+
+func containerPortAddress(container *docker.Container, port int) string {
+	if container.NetworkSettings != nil {
+		for _, binding := range container.NetworkSettings.Ports[docker.Port(strconv.Itoa(port)+"/tcp")] {
+			if binding.HostPort == "" {
+				continue
+			}
+			hostIP := binding.HostIP
+			if hostIP == "" || hostIP == "0.0.0.0" {
+				hostIP = "localhost"
+			}
+			return net.JoinHostPort(hostIP, binding.HostPort)
+		}
+	}
+
+	ipAddress := containerIPAddress(container)
+	if ipAddress == "" {
+		return ""
+	}
+	return net.JoinHostPort(ipAddress, strconv.Itoa(port))
+}
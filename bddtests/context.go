@@ -12,13 +12,18 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/DATA-DOG/godog/gherkin"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/staticselection"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	mspApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
@@ -45,9 +50,15 @@ type BDDContext struct {
 	peersByChannel         map[string][]*PeerConfig
 	orgsByChannel          map[string][]string
 	collectionConfigs      map[string]CollectionConfigCreator
-	resmgmtClients         map[string]*resmgmt.Client
-	contexts               map[string]contextApi.Client
-	orgChannelClients      map[string]*channel.Client
+	chaincodePackages      map[string]*ChaincodePackage
+	transientMaps          map[string]map[string][]byte
+	ccEventSubscriptions   []*ChaincodeEventSubscription
+	blockListeners         map[string]*BlockListener
+	resmgmtClients         map[orgUser]*resmgmt.Client
+	contexts               map[orgUser]contextApi.Client
+	orgChannelClients      map[orgUserChannel]*channel.Client
+	identities             map[orgUser]fabsdk.ContextOption
+	enrollmentSecrets      map[orgUser]string
 	peersMspID             map[string]string
 	clientConfigFilePath   string
 	clientConfigFileName   string
@@ -56,6 +67,11 @@ type BDDContext struct {
 	createdChannels        map[string]bool
 	sdk                    *fabsdk.FabricSDK
 	serviceProviderFactory sdkApi.ServiceProviderFactory
+	featureName            string
+	scenarioName           string
+	scenarioStart          time.Time
+	scenarioLogDir         string
+	vars                   *VarStore
 }
 
 // PeerConfig holds the peer configuration and org ID
@@ -73,11 +89,16 @@ func NewBDDContext(orgs []string, ordererOrgID string, clientConfigFilePath stri
 	instance := BDDContext{
 		orgs:                 orgs,
 		peersByChannel:       make(map[string][]*PeerConfig),
-		contexts:             make(map[string]contextApi.Client),
+		contexts:             make(map[orgUser]contextApi.Client),
 		orgsByChannel:        make(map[string][]string),
-		resmgmtClients:       make(map[string]*resmgmt.Client),
+		resmgmtClients:       make(map[orgUser]*resmgmt.Client),
 		collectionConfigs:    make(map[string]CollectionConfigCreator),
-		orgChannelClients:    make(map[string]*channel.Client),
+		chaincodePackages:    make(map[string]*ChaincodePackage),
+		transientMaps:        make(map[string]map[string][]byte),
+		blockListeners:       make(map[string]*BlockListener),
+		orgChannelClients:    make(map[orgUserChannel]*channel.Client),
+		identities:           make(map[orgUser]fabsdk.ContextOption),
+		enrollmentSecrets:    make(map[orgUser]string),
 		createdChannels:      make(map[string]bool),
 		clientConfigFilePath: clientConfigFilePath,
 		clientConfigFileName: clientConfigFileName,
@@ -85,15 +106,36 @@ func NewBDDContext(orgs []string, ordererOrgID string, clientConfigFilePath stri
 		systemCCPath:         systemCCPath,
 		testCCPath:           testCCPath,
 		ordererOrgID:         ordererOrgID,
+		vars:                 NewVarStore(),
 	}
+
 	return &instance, nil
 }
 
+// BeforeFeature execute code before bdd feature
+func (b *BDDContext) BeforeFeature(feature *gherkin.Feature) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.featureName = feature.Name
+	b.vars.Clear(FeatureScope)
+}
+
 // BeforeScenario execute code before bdd scenario
 func (b *BDDContext) BeforeScenario(scenarioOrScenarioOutline interface{}) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
+	if b.scenarioStart.IsZero() {
+		b.scenarioStart = time.Now()
+		switch scenario := scenarioOrScenarioOutline.(type) {
+		case *gherkin.Scenario:
+			b.scenarioName = scenario.Name
+		case *gherkin.ScenarioOutline:
+			b.scenarioName = scenario.Name
+		}
+	}
+
 	if b.sdk != nil {
 		return
 	}
@@ -121,26 +163,12 @@ func (b *BDDContext) BeforeScenario(scenarioOrScenarioOutline interface{}) {
 	b.clientConfig = endpointConfig
 	for _, org := range b.orgs {
 		// load org admin
-		orgAdmin := fmt.Sprintf("%s_%s", org, ADMIN)
-		adminContextProv := sdk.Context(fabsdk.WithUser("Admin"), fabsdk.WithOrg(org))
-		b.contexts[orgAdmin], err = adminContextProv()
-		if err != nil {
-			panic(fmt.Sprintf("Failed to get admin context: %s", err))
-		}
-		b.resmgmtClients[orgAdmin], err = resmgmt.New(adminContextProv)
-		if err != nil {
-			panic(fmt.Sprintf("Failed to get admin resmgmt: %s", err))
+		if err := b.loadOrgUser(org, ADMIN); err != nil {
+			panic(fmt.Sprintf("Failed to load admin: %s", err))
 		}
 		// load org user
-		orgUser := fmt.Sprintf("%s_%s", org, USER)
-		userContextProv := sdk.Context(fabsdk.WithUser("User1"), fabsdk.WithOrg(org))
-		b.contexts[orgUser], err = userContextProv()
-		if err != nil {
-			panic(fmt.Sprintf("Failed to get user context: %s", err))
-		}
-		b.resmgmtClients[orgUser], err = resmgmt.New(userContextProv)
-		if err != nil {
-			panic(fmt.Sprintf("Failed to get user resmgmt: %s", err))
+		if err := b.loadOrgUser(org, USER); err != nil {
+			panic(fmt.Sprintf("Failed to load user: %s", err))
 		}
 	}
 
@@ -152,17 +180,46 @@ func (b *BDDContext) AfterScenario(interface{}, error) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
+	for _, sub := range b.ccEventSubscriptions {
+		sub.Close()
+	}
+	b.ccEventSubscriptions = nil
+
+	for _, l := range b.blockListeners {
+		l.Close()
+	}
+	b.blockListeners = make(map[string]*BlockListener)
+
+	b.vars.Clear(ScenarioScope)
+
+	if !b.scenarioStart.IsZero() {
+		b.captureScenarioLogs()
+		b.scenarioStart = time.Time{}
+	}
+
+	// Remove any network faults and heal any network partitions so that they don't affect subsequent scenarios
+	if b.composition != nil {
+		if err := b.composition.RemoveAllNetworkFaults(); err != nil {
+			logger.Errorf("%s", err)
+		}
+		if err := b.composition.HealNetworkPartitions(); err != nil {
+			logger.Errorf("%s", err)
+		}
+	}
+
 	if b.sdk != nil {
 		b.sdk.Close()
 		b.sdk = nil
 	}
 
 	b.peersByChannel = make(map[string][]*PeerConfig)
-	b.contexts = make(map[string]contextApi.Client)
+	b.contexts = make(map[orgUser]contextApi.Client)
 	b.orgsByChannel = make(map[string][]string)
-	b.resmgmtClients = make(map[string]*resmgmt.Client)
+	b.resmgmtClients = make(map[orgUser]*resmgmt.Client)
 	b.collectionConfigs = make(map[string]CollectionConfigCreator)
-	b.orgChannelClients = make(map[string]*channel.Client)
+	b.chaincodePackages = make(map[string]*ChaincodePackage)
+	b.transientMaps = make(map[string]map[string][]byte)
+	b.orgChannelClients = make(map[orgUserChannel]*channel.Client)
 	b.createdChannels = make(map[string]bool)
 }
 
@@ -227,37 +284,230 @@ func (b *BDDContext) DefineCollectionConfig(id string, creator CollectionConfigC
 	b.collectionConfigs[id] = creator
 }
 
-// ResMgmtClient returns the res mgmt client
+// ChaincodePackage returns the chaincode package for the given label.
+// If the package does not exist then nil is returned.
+func (b *BDDContext) ChaincodePackage(label string) *ChaincodePackage {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.chaincodePackages[label]
+}
+
+// AddChaincodePackage registers a chaincode package (created for the new chaincode lifecycle) by label
+func (b *BDDContext) AddChaincodePackage(pkg *ChaincodePackage) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.chaincodePackages[pkg.Label] = pkg
+}
+
+// TransientMap returns the transient map with the given name.
+// If the transient map does not exist then nil is returned.
+func (b *BDDContext) TransientMap(name string) map[string][]byte {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.transientMaps[name]
+}
+
+// DefineTransientMap defines a named transient map which may be passed to chaincode in subsequent
+// invokes and queries. All transient maps are removed at the end of the scenario.
+func (b *BDDContext) DefineTransientMap(name string, transientMap map[string][]byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.transientMaps[name] = transientMap
+}
+
+// BlockListener returns the block listener for the given channel or nil if no listener was started
+func (b *BDDContext) BlockListener(channelID string) *BlockListener {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.blockListeners[channelID]
+}
+
+// SetBlockListener sets the block listener for the listener's channel, closing any existing listener.
+// All block listeners are closed at the end of the scenario.
+func (b *BDDContext) SetBlockListener(l *BlockListener) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if existing, ok := b.blockListeners[l.ChannelID()]; ok {
+		existing.Close()
+	}
+	b.blockListeners[l.ChannelID()] = l
+}
+
+// ResMgmtClient returns the res mgmt client. If the client cannot be created
+// for the given user then the error is logged and nil is returned.
 func (b *BDDContext) ResMgmtClient(org, userType string) *resmgmt.Client {
+	client, err := b.OrgResMgmtClient(org, userType)
+	if err != nil {
+		logger.Errorf("Error getting resmgmt client for user [%s] of org [%s]: %s", userType, org, err)
+		return nil
+	}
+	return client
+}
+
+// OrgResMgmtClient returns the res mgmt client for the given user of the given org. The user type
+// may be ADMIN, USER or the name of an identity (see RegisterUser and RegisterIdentity).
+func (b *BDDContext) OrgResMgmtClient(org, userType string) (*resmgmt.Client, error) {
+	if err := b.ensureOrgUserLoaded(org, userType); err != nil {
+		return nil, err
+	}
+
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
-	return b.resmgmtClients[fmt.Sprintf("%s_%s", org, userType)]
+	return b.resmgmtClients[orgUser{org: org, user: userType}], nil
 }
 
 // OrgChannelClient returns the org channel client
 func (b *BDDContext) OrgChannelClient(org, userType, channelID string) (*channel.Client, error) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
-	if orgChanClient, ok := b.orgChannelClients[fmt.Sprintf("%s_%s_%s", org, userType, channelID)]; ok {
+	key := orgUserChannel{orgUser: orgUser{org: org, user: userType}, channelID: channelID}
+	if orgChanClient, ok := b.orgChannelClients[key]; ok {
 		return orgChanClient, nil
 	}
-	user := "Admin"
-	if userType == USER {
-		user = "User1"
-	}
-	orgChanClient, err := channel.New(b.sdk.ChannelContext(channelID, fabsdk.WithUser(user), fabsdk.WithOrg(org)))
+	orgChanClient, err := channel.New(b.sdk.ChannelContext(channelID, b.identityOption(org, userType), fabsdk.WithOrg(org)))
 	if err != nil {
 		return nil, err
 	}
-	b.orgChannelClients[fmt.Sprintf("%s_%s_%s", org, userType, channelID)] = orgChanClient
+	b.orgChannelClients[key] = orgChanClient
 	return orgChanClient, nil
 }
 
-// OrgUserContext returns the org user context
+// OrgLedgerClient returns a ledger client for the given org and channel
+func (b *BDDContext) OrgLedgerClient(org, userType, channelID string) (*ledger.Client, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return ledger.New(b.sdk.ChannelContext(channelID, b.identityOption(org, userType), fabsdk.WithOrg(org)))
+}
+
+// OrgEventClient returns a new event client for the given org and channel
+func (b *BDDContext) OrgEventClient(org, userType, channelID string, opts ...event.ClientOption) (*event.Client, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return event.New(b.sdk.ChannelContext(channelID, b.identityOption(org, userType), fabsdk.WithOrg(org)), opts...)
+}
+
+// AddChaincodeEventSubscription adds a chaincode event subscription. All subscriptions
+// are closed at the end of the scenario.
+func (b *BDDContext) AddChaincodeEventSubscription(sub *ChaincodeEventSubscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.ccEventSubscriptions = append(b.ccEventSubscriptions, sub)
+}
+
+// ChaincodeEventSubscriptions returns all chaincode event subscriptions for the current scenario
+func (b *BDDContext) ChaincodeEventSubscriptions() []*ChaincodeEventSubscription {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.ccEventSubscriptions
+}
+
+// OrgUserContext returns the org user context. If the context cannot be created
+// for the given user then the error is logged and nil is returned.
 func (b *BDDContext) OrgUserContext(org, userType string) contextApi.Client {
+	if err := b.ensureOrgUserLoaded(org, userType); err != nil {
+		logger.Errorf("Error getting context for user [%s] of org [%s]: %s", userType, org, err)
+		return nil
+	}
+
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
-	return b.contexts[fmt.Sprintf("%s_%s", org, userType)]
+	return b.contexts[orgUser{org: org, user: userType}]
+}
+
+// RegisterUser registers a named identity for the given org which uses the crypto material of the given
+// user in the SDK config (e.g. "User2"). The name may then be used as the user type in subsequent
+// requests. Registered identities remain registered for the remainder of the test suite. Note that a name
+// which has not been registered is resolved as the name of a user in the SDK config.
+func (b *BDDContext) RegisterUser(org, name, userName string) {
+	b.registerIdentity(org, name, fabsdk.WithUser(userName))
+}
+
+// RegisterIdentity registers a named identity for the given org which uses the given signing identity
+// (for example, an identity enrolled with a Fabric CA). The name may then be used as the user type in
+// subsequent requests. Registered identities remain registered for the remainder of the test suite.
+func (b *BDDContext) RegisterIdentity(org, name string, identity mspApi.SigningIdentity) {
+	b.registerIdentity(org, name, fabsdk.WithIdentity(identity))
+}
+
+func (b *BDDContext) registerIdentity(org, name string, opt fabsdk.ContextOption) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := orgUser{org: org, user: name}
+	b.identities[key] = opt
+
+	// Remove any clients that were created for a previously registered identity with the same name
+	delete(b.contexts, key)
+	delete(b.resmgmtClients, key)
+	for k := range b.orgChannelClients {
+		if k.orgUser == key {
+			delete(b.orgChannelClients, k)
+		}
+	}
+}
+
+// EnrollmentSecret returns the enrollment secret of the given user which was registered with the org's CA.
+// Returns false if the user was not registered.
+func (b *BDDContext) EnrollmentSecret(org, name string) (string, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	secret, ok := b.enrollmentSecrets[orgUser{org: org, user: name}]
+	return secret, ok
+}
+
+// SetEnrollmentSecret sets the enrollment secret of the given user which was registered with the org's CA.
+// Enrollment secrets are retained for the remainder of the test suite.
+func (b *BDDContext) SetEnrollmentSecret(org, name, secret string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.enrollmentSecrets[orgUser{org: org, user: name}] = secret
+}
+
+// ensureOrgUserLoaded loads the context and res mgmt client for the given user if not already loaded
+func (b *BDDContext) ensureOrgUserLoaded(org, userType string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.contexts[orgUser{org: org, user: userType}]; ok {
+		return nil
+	}
+
+	if b.sdk == nil {
+		return fmt.Errorf("SDK is not initialized")
+	}
+
+	return b.loadOrgUser(org, userType)
+}
+
+// loadOrgUser loads the context and res mgmt client for the given user. The mutex must be held by the caller.
+func (b *BDDContext) loadOrgUser(org, userType string) error {
+	key := orgUser{org: org, user: userType}
+
+	contextProv := b.sdk.Context(b.identityOption(org, userType), fabsdk.WithOrg(org))
+	ctx, err := contextProv()
+	if err != nil {
+		return fmt.Errorf("failed to get context for user [%s] of org [%s]: %s", userType, org, err)
+	}
+
+	client, err := resmgmt.New(contextProv)
+	if err != nil {
+		return fmt.Errorf("failed to get resmgmt client for user [%s] of org [%s]: %s", userType, org, err)
+	}
+
+	b.contexts[key] = ctx
+	b.resmgmtClients[key] = client
+	return nil
+}
+
+// identityOption returns the SDK context option for the given user. If an identity was registered with the
+// given name (see RegisterUser and RegisterIdentity) then it is used, otherwise the user type is resolved as the
+// name of a user in the SDK config (see userName). Note that only the ADMIN and USER contexts are loaded eagerly
+// in BeforeScenario; any other user is loaded when first used. The mutex must be held by the caller.
+func (b *BDDContext) identityOption(org, userType string) fabsdk.ContextOption {
+	if opt, ok := b.identities[orgUser{org: org, user: userType}]; ok {
+		return opt
+	}
+	return fabsdk.WithUser(userName(userType))
 }
 
 // ClientConfig returns client config
@@ -358,6 +608,15 @@ func (b *BDDContext) OrgIDForChannel(channelID string) (string, error) {
 	return orgIDs[rand.Intn(len(orgIDs))], nil
 }
 
+// OrgMSPID returns the MSP ID of the given org from the network config
+func (b *BDDContext) OrgMSPID(orgID string) (string, error) {
+	orgConfig, ok := b.clientConfig.NetworkConfig().Organizations[strings.ToLower(orgID)]
+	if !ok {
+		return "", fmt.Errorf("org config not found for org ID %s", orgID)
+	}
+	return orgConfig.MSPID, nil
+}
+
 // Sdk return sdk instance
 func (b *BDDContext) Sdk() *fabsdk.FabricSDK {
 	return b.sdk
@@ -383,6 +642,55 @@ func (b *BDDContext) SetComposition(composition *Composition) {
 	b.composition = composition
 }
 
+// Vars returns the variable store for the context
+func (b *BDDContext) Vars() *VarStore {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.vars
+}
+
+// SetVarStore sets the variable store of the context. By default, each context has its own variable store
+// so that contexts which run concurrently (e.g. when godog runs with concurrency) don't interfere with each other.
+// A context may be given the shared variable store (see SharedVarStore) so that the variables are also visible to
+// the package-level variable functions (SetVar, GetVar, etc.). The store should be set before any steps are run.
+func (b *BDDContext) SetVarStore(vars *VarStore) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.vars = vars
+}
+
+// SetScenarioLogDir sets the base directory into which the logs of all containers are captured at the end
+// of each scenario. The logs are written to a sub-directory named after the feature and scenario.
+// Log capture is disabled by default and is disabled again if the directory is empty.
+func (b *BDDContext) SetScenarioLogDir(dir string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.scenarioLogDir = dir
+}
+
+// ScenarioStartTime returns the time at which the current scenario started
+func (b *BDDContext) ScenarioStartTime() time.Time {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.scenarioStart
+}
+
+func (b *BDDContext) captureScenarioLogs() {
+	if b.composition == nil || b.scenarioLogDir == "" {
+		return
+	}
+
+	dir := scenarioLogDir(b.scenarioLogDir, b.featureName, b.scenarioName)
+	logger.Infof("Capturing container logs for scenario [%s] to %s", b.scenarioName, dir)
+	if err := b.composition.CaptureLogs(dir, b.scenarioStart); err != nil {
+		logger.Errorf("Error capturing container logs for scenario [%s]: %s", b.scenarioName, err)
+	}
+}
+
 // Composition returns the Docker composition
 func (b *BDDContext) Composition() *Composition {
 	b.mutex.RLock()
@@ -439,6 +747,31 @@ func (b *BDDContext) populateChannelPeers() {
 	}
 }
 
+// userName returns the name of the user in the SDK config for the given user type. ADMIN and USER are mapped
+// to "Admin" and "User1" respectively; any other user type is used as the name of the user, e.g. "User2".
+func userName(userType string) string {
+	switch userType {
+	case USER:
+		return "User1"
+	case ADMIN:
+		return "Admin"
+	default:
+		return userType
+	}
+}
+
+// orgUser is the key of the identities and clients which are cached per user of an org
+type orgUser struct {
+	org  string
+	user string
+}
+
+// orgUserChannel is the key of the clients which are cached per user of an org and channel
+type orgUserChannel struct {
+	orgUser
+	channelID string
+}
+
 // StaticSelectionProviderFactory uses a static selection service
 // that doesn't use CC policies. (Required for System CC invocations.)
 type StaticSelectionProviderFactory struct {
@@ -0,0 +1,159 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserName(t *testing.T) {
+	assert.Equal(t, "Admin", userName(ADMIN))
+	assert.Equal(t, "User1", userName(USER))
+	assert.Equal(t, "reader", userName("reader"))
+}
+
+func TestIdentityOption(t *testing.T) {
+	sdk := newTestSDK(t, "org1", "Admin", "User1", "User2")
+	defer sdk.Close()
+
+	b := newTestBDDContext()
+
+	// userID returns the ID of the identity of the SDK context which is created with the given user's option
+	userID := func(org, userType string) (string, error) {
+		ctx, err := sdk.Context(b.identityOption(org, userType), fabsdk.WithOrg("org1"))()
+		if err != nil {
+			return "", err
+		}
+		return ctx.Identifier().ID, nil
+	}
+
+	// Names which are not registered are resolved as users in the SDK config
+	for userType, expected := range map[string]string{ADMIN: "Admin", USER: "User1", "User2": "User2"} {
+		id, err := userID("org1", userType)
+		require.NoError(t, err)
+		assert.Equal(t, expected, id)
+	}
+	_, err := userID("org1", "reader")
+	assert.EqualError(t, err, msp.ErrUserNotFound.Error())
+
+	b.RegisterUser("org1", "reader", "User2")
+	id, err := userID("org1", "reader")
+	require.NoError(t, err)
+	assert.Equal(t, "User2", id)
+
+	// The name is only registered for the given org
+	_, err = userID("org2", "reader")
+	assert.EqualError(t, err, msp.ErrUserNotFound.Error())
+
+	b.RegisterIdentity("org1", "writer", mockmsp.NewMockSigningIdentity("writer", "Org1MSP"))
+	id, err = userID("org1", "writer")
+	require.NoError(t, err)
+	assert.Equal(t, "writer", id)
+}
+
+func TestRegisterIdentity(t *testing.T) {
+	b := newTestBDDContext()
+
+	keys := []orgUser{
+		{org: "org1", user: "reader"},
+		{org: "org1", user: "reader_admin"},
+		{org: "org1_reader", user: "admin"},
+		{org: "org2", user: "reader"},
+	}
+	for _, key := range keys {
+		b.contexts[key] = nil
+		b.resmgmtClients[key] = &resmgmt.Client{}
+		b.orgChannelClients[orgUserChannel{orgUser: key, channelID: "mychannel"}] = &channel.Client{}
+		b.orgChannelClients[orgUserChannel{orgUser: key, channelID: "yourchannel"}] = &channel.Client{}
+	}
+
+	b.RegisterUser("org1", "reader", "User2")
+
+	// Only the clients of the re-registered identity are removed
+	removed := keys[0]
+	for _, key := range keys {
+		_, ok := b.contexts[key]
+		assert.Equal(t, key != removed, ok, "context for %v", key)
+		_, ok = b.resmgmtClients[key]
+		assert.Equal(t, key != removed, ok, "resmgmt client for %v", key)
+		_, ok = b.orgChannelClients[orgUserChannel{orgUser: key, channelID: "mychannel"}]
+		assert.Equal(t, key != removed, ok, "channel client for %v", key)
+		_, ok = b.orgChannelClients[orgUserChannel{orgUser: key, channelID: "yourchannel"}]
+		assert.Equal(t, key != removed, ok, "channel client for %v", key)
+	}
+}
+
+func newTestBDDContext() *BDDContext {
+	return &BDDContext{
+		contexts:          make(map[orgUser]contextApi.Client),
+		resmgmtClients:    make(map[orgUser]*resmgmt.Client),
+		orgChannelClients: make(map[orgUserChannel]*channel.Client),
+		identities:        make(map[orgUser]fabsdk.ContextOption),
+		transientMaps:     make(map[string]map[string][]byte),
+		vars:              NewVarStore(),
+	}
+}
+
+// newTestSDK returns an SDK whose config contains the given users of the given org. A new key and self-signed
+// certificate is generated for each user.
+func newTestSDK(t *testing.T, org string, users ...string) *fabsdk.FabricSDK {
+	cfg := fmt.Sprintf("version: 1.0.0\nclient:\n  organization: %s\norganizations:\n  %s:\n    mspid: Org1MSP\n    users:\n", org, org)
+	for _, user := range users {
+		cert, key := newTestCertAndKey(t, user)
+		cfg += fmt.Sprintf("      %s:\n        cert:\n          pem: |\n%s        key:\n          pem: |\n%s", user, indent(cert, 12), indent(key, 12))
+	}
+
+	sdk, err := fabsdk.New(config.FromRaw([]byte(cfg), "yaml"))
+	require.NoError(t, err)
+	return sdk
+}
+
+// newTestCertAndKey returns a PEM-encoded self-signed certificate and private key for the given common name
+func newTestCertAndKey(t *testing.T, cn string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}))
+}
+
+func indent(s string, n int) string {
+	prefix := strings.Repeat(" ", n)
+	return prefix + strings.Replace(strings.TrimSuffix(s, "\n"), "\n", "\n"+prefix, -1) + "\n"
+}
@@ -7,18 +7,116 @@ SPDX-License-Identifier: Apache-2.0
 package bddtests
 
 import (
+	"bytes"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
 )
 
 // DockerHelper helper for docker specific functions
 type DockerHelper interface {
 	GetIPAddress(containerID string) (string, error)
 	RemoveContainersWithNamePrefix(namePrefix string) error
+	Exec(containerID string, cmd ...string) (string, error)
+}
+
+// NewDockerAPIHelper returns a new DockerHelper instance which uses the Docker Engine API
+func NewDockerAPIHelper(client *docker.Client) (DockerHelper, error) {
+	if client == nil {
+		return nil, fmt.Errorf("docker client is nil")
+	}
+	return &dockerAPIHelper{client: client}, nil
+}
+
+type dockerAPIHelper struct {
+	client *docker.Client
+}
+
+func (d *dockerAPIHelper) GetIPAddress(containerID string) (string, error) {
+	container, err := d.client.InspectContainer(containerID)
+	if err != nil {
+		return "", fmt.Errorf("Error getting IPAddress for container '%s':  %s", containerID, err)
+	}
+	ipAddress := containerIPAddress(container)
+	if ipAddress == "" {
+		return "", fmt.Errorf("Error getting IPAddress for container '%s':  no IP address assigned", containerID)
+	}
+	return ipAddress, nil
+}
+
+func (d *dockerAPIHelper) RemoveContainersWithNamePrefix(namePrefix string) error {
+	containers, err := d.client.ListContainers(docker.ListContainersOptions{All: true, Filters: map[string][]string{"name": {namePrefix}}})
+	if err != nil {
+		return fmt.Errorf("Error removing containers with name prefix (%s):  %s", namePrefix, err)
+	}
+	for _, container := range containers {
+		logger.Infof("Removing container: %s", container.ID)
+		if err := d.client.RemoveContainer(docker.RemoveContainerOptions{ID: container.ID, Force: true}); err != nil {
+			logger.Warnf("Error removing container %s: %s", container.ID, err)
+		}
+	}
+	return nil
+}
+
+// Exec runs the given command in the container and returns the combined output. An error is returned
+// if the command exits with a non-zero exit code.
+func (d *dockerAPIHelper) Exec(containerID string, cmd ...string) (string, error) {
+	exec, err := d.client.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error creating exec %s in container '%s':  %s", cmd, containerID, err)
+	}
+
+	var output bytes.Buffer
+	if err := d.client.StartExec(exec.ID, docker.StartExecOptions{OutputStream: &output, ErrorStream: &output}); err != nil {
+		return "", fmt.Errorf("Error running exec %s in container '%s':  %s", cmd, containerID, err)
+	}
+
+	inspect, err := d.client.InspectExec(exec.ID)
+	if err != nil {
+		return "", fmt.Errorf("Error inspecting exec %s in container '%s':  %s", cmd, containerID, err)
+	}
+	if inspect.ExitCode != 0 {
+		return output.String(), fmt.Errorf("Exec %s in container '%s' exited with code %d:  %s", cmd, containerID, inspect.ExitCode, output.String())
+	}
+	return output.String(), nil
+}
+
+// containerIPAddress returns the IP address of the container. Containers started by docker-compose are
+// attached to user-defined networks, so the address from the first network (by name) is returned
+// if the default bridge address isn't set.
+func containerIPAddress(container *docker.Container) string {
+	if container.NetworkSettings == nil {
+		return ""
+	}
+	if container.NetworkSettings.IPAddress != "" {
+		return container.NetworkSettings.IPAddress
+	}
+
+	var networkNames []string
+	for name := range container.NetworkSettings.Networks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+
+	for _, name := range networkNames {
+		if ipAddress := container.NetworkSettings.Networks[name].IPAddress; ipAddress != "" {
+			return ipAddress
+		}
+	}
+	return ""
 }
 
 // NewDockerCmdlineHelper returns a new command line DockerHelper instance
+//
+// Deprecated: The docker command line is no longer required. Use NewDockerAPIHelper instead.
 func NewDockerCmdlineHelper() (DockerHelper, error) {
 	dockerCmdlineHelper := &dockerCmdlineHelper{}
 	return dockerCmdlineHelper, nil
@@ -75,6 +173,14 @@ func (d *dockerCmdlineHelper) GetIPAddress(containerID string) (ipAddress string
 	return ipAddress, nil
 }
 
+func (d *dockerCmdlineHelper) Exec(containerID string, cmd ...string) (string, error) {
+	cmdOutput, err := d.issueDockerCommand(append([]string{"exec", containerID}, cmd...))
+	if err != nil {
+		return cmdOutput, fmt.Errorf("Error running exec %s in container '%s':  %s (%s)", cmd, containerID, err, cmdOutput)
+	}
+	return cmdOutput, nil
+}
+
 func (d *dockerCmdlineHelper) RemoveContainersWithNamePrefix(namePrefix string) error {
 	containers, err := d.getContainerIDsWithNamePrefix(namePrefix)
 	if err != nil {
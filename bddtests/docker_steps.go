@@ -7,7 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package bddtests
 
 import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/DATA-DOG/godog"
+	"github.com/pkg/errors"
 )
 
 // DockerSteps manages Docker BDD steps
@@ -24,30 +30,177 @@ func NewDockerSteps(context *BDDContext) *DockerSteps {
 
 func (d *DockerSteps) startContainer(containerID string) error {
 	logger.Infof("Starting Docker container [%s]", containerID)
-	_, err := d.BDDContext.Composition().issueCommand("start", containerID)
-	return err
+	return d.BDDContext.Composition().StartContainer(containerID)
 }
 
 func (d *DockerSteps) stopContainer(containerID string) error {
 	logger.Infof("Stopping Docker container [%s]", containerID)
-	_, err := d.BDDContext.Composition().issueCommand("stop", containerID)
-	return err
+	return d.BDDContext.Composition().StopContainer(containerID)
 }
 
 func (d *DockerSteps) pauseContainer(containerID string) error {
 	logger.Infof("Pausing Docker container [%s]", containerID)
-	_, err := d.BDDContext.Composition().issueCommand("pause", containerID)
-	return err
+	return d.BDDContext.Composition().PauseContainer(containerID)
 }
 
 func (d *DockerSteps) unpauseContainer(containerID string) error {
 	logger.Infof("Un-pausing Docker container [%s]", containerID)
-	_, err := d.BDDContext.Composition().issueCommand("unpause", containerID)
-	return err
+	return d.BDDContext.Composition().UnpauseContainer(containerID)
+}
+
+func (d *DockerSteps) killContainer(containerID string) error {
+	logger.Infof("Killing Docker container [%s]", containerID)
+	return d.BDDContext.Composition().KillContainer(containerID)
+}
+
+func (d *DockerSteps) restartContainer(containerID string) error {
+	logger.Infof("Restarting Docker container [%s]", containerID)
+	return d.BDDContext.Composition().RestartContainer(containerID)
+}
+
+func (d *DockerSteps) disconnectContainer(containerID string) error {
+	logger.Infof("Disconnecting Docker container [%s] from the network", containerID)
+	return d.BDDContext.Composition().DisconnectContainer(containerID)
+}
+
+func (d *DockerSteps) reconnectContainer(containerID string) error {
+	logger.Infof("Reconnecting Docker container [%s] to the network", containerID)
+	return d.BDDContext.Composition().ReconnectContainer(containerID)
+}
+
+func (d *DockerSteps) partitionContainers(group1, group2 string) error {
+	logger.Infof("Partitioning Docker containers [%s] from [%s]", group1, group2)
+	return d.BDDContext.Composition().PartitionContainers(splitContainerList(group1), splitContainerList(group2))
+}
+
+func (d *DockerSteps) healNetworkPartitions() error {
+	logger.Infof("Healing network partitions")
+	return d.BDDContext.Composition().HealNetworkPartitions()
+}
+
+func (d *DockerSteps) addNetworkLatency(containerID string, delay int) error {
+	return d.applyNetworkFault(containerID, NetworkFault{Delay: time.Duration(delay) * time.Millisecond})
+}
+
+func (d *DockerSteps) addNetworkLatencyWithJitter(containerID string, delay, jitter int) error {
+	return d.applyNetworkFault(containerID, NetworkFault{Delay: time.Duration(delay) * time.Millisecond, Jitter: time.Duration(jitter) * time.Millisecond})
+}
+
+func (d *DockerSteps) addPacketLoss(containerID string, loss string) error {
+	lossPercent, err := strconv.ParseFloat(loss, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid packet loss percentage [%s]", loss)
+	}
+	if lossPercent <= 0 || lossPercent > 100 {
+		return errors.Errorf("packet loss percentage must be greater than 0 and at most 100: %s", loss)
+	}
+	return d.applyNetworkFault(containerID, NetworkFault{Loss: lossPercent})
+}
+
+func (d *DockerSteps) limitBandwidth(containerID string, rate string) error {
+	return d.applyNetworkFault(containerID, NetworkFault{Rate: rate})
+}
+
+func (d *DockerSteps) applyNetworkFault(containerID string, fault NetworkFault) error {
+	logger.Infof("Applying network fault %+v to Docker container [%s]", fault, containerID)
+	return d.BDDContext.Composition().ApplyNetworkFault(containerID, fault)
+}
+
+func (d *DockerSteps) removeNetworkFault(containerID string) error {
+	logger.Infof("Removing network faults from Docker container [%s]", containerID)
+	return d.BDDContext.Composition().RemoveNetworkFault(containerID)
+}
+
+func (d *DockerSteps) waitForHealthy(containerID string, seconds int) error {
+	return d.BDDContext.Composition().WaitForHealthy(containerID, time.Duration(seconds)*time.Second)
+}
+
+func (d *DockerSteps) waitForPort(port int, containerID string, seconds int) error {
+	return d.BDDContext.Composition().WaitForPort(containerID, port, time.Duration(seconds)*time.Second)
+}
+
+func (d *DockerSteps) waitForLogLine(containerID, expr string, seconds int) error {
+	expr, err := d.BDDContext.Vars().Resolve(expr)
+	if err != nil {
+		return err
+	}
+
+	line, err := d.BDDContext.Composition().WaitForLogLine(containerID, expr, time.Duration(seconds)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Docker container [%s] logged line: %s", containerID, line)
+	return nil
+}
+
+func (d *DockerSteps) logsContain(containerID, expr string) error {
+	return d.checkLogs(containerID, expr, true)
+}
+
+func (d *DockerSteps) logsDoNotContain(containerID, expr string) error {
+	return d.checkLogs(containerID, expr, false)
+}
+
+// captureLogsToDir enables the capture of all container logs at the end of each scenario (including the current
+// scenario) to a sub-directory of the given directory. An empty directory disables log capture.
+func (d *DockerSteps) captureLogsToDir(dir string) error {
+	dir, err := d.BDDContext.Vars().Resolve(dir)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Capturing container logs to [%s] at the end of each scenario", dir)
+	d.BDDContext.SetScenarioLogDir(dir)
+	return nil
+}
+
+func (d *DockerSteps) checkLogs(containerID, expr string, expectMatch bool) error {
+	expr, err := d.BDDContext.Vars().Resolve(expr)
+	if err != nil {
+		return err
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid regular expression [%s]", expr)
+	}
+
+	logs, err := d.BDDContext.Composition().ContainerLogsSince(containerID, d.BDDContext.ScenarioStartTime())
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(logs, "\n") {
+		if !regex.MatchString(line) {
+			continue
+		}
+		if !expectMatch {
+			return errors.Errorf("logs of Docker container [%s] contain a line matching [%s]: %s", containerID, expr, line)
+		}
+		logger.Infof("Logs of Docker container [%s] contain line: %s", containerID, line)
+		return nil
+	}
+
+	if expectMatch {
+		return errors.Errorf("logs of Docker container [%s] do not contain a line matching [%s] since the scenario started", containerID, expr)
+	}
+	return nil
+}
+
+func splitContainerList(containers string) []string {
+	var ids []string
+	for _, id := range strings.Split(containers, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // RegisterSteps register steps
 func (d *DockerSteps) RegisterSteps(s *godog.Suite) {
+	s.BeforeFeature(d.BDDContext.BeforeFeature)
 	s.BeforeScenario(d.BDDContext.BeforeScenario)
 	s.AfterScenario(d.BDDContext.AfterScenario)
 
@@ -55,4 +208,21 @@ func (d *DockerSteps) RegisterSteps(s *godog.Suite) {
 	s.Step(`^container "([^"]*)" is stopped$`, d.stopContainer)
 	s.Step(`^container "([^"]*)" is paused$`, d.pauseContainer)
 	s.Step(`^container "([^"]*)" is unpaused$`, d.unpauseContainer)
+	s.Step(`^container "([^"]*)" is killed$`, d.killContainer)
+	s.Step(`^container "([^"]*)" is restarted$`, d.restartContainer)
+	s.Step(`^container "([^"]*)" is disconnected from the network$`, d.disconnectContainer)
+	s.Step(`^container "([^"]*)" is reconnected to the network$`, d.reconnectContainer)
+	s.Step(`^(?:containers|peers) "([^"]*)" are partitioned from (?:containers|peers) "([^"]*)"$`, d.partitionContainers)
+	s.Step(`^the network partition is healed$`, d.healNetworkPartitions)
+	s.Step(`^container "([^"]*)" has a network latency of (\d+)ms$`, d.addNetworkLatency)
+	s.Step(`^container "([^"]*)" has a network latency of (\d+)ms with (\d+)ms jitter$`, d.addNetworkLatencyWithJitter)
+	s.Step(`^container "([^"]*)" has (\d+(?:\.\d+)?)% packet loss$`, d.addPacketLoss)
+	s.Step(`^container "([^"]*)" has its bandwidth limited to "([^"]*)"$`, d.limitBandwidth)
+	s.Step(`^network faults are removed from container "([^"]*)"$`, d.removeNetworkFault)
+	s.Step(`^container "([^"]*)" is healthy within (\d+) seconds$`, d.waitForHealthy)
+	s.Step(`^port (\d+) of container "([^"]*)" accepts connections within (\d+) seconds$`, d.waitForPort)
+	s.Step(`^container "([^"]*)" logs a line matching "([^"]*)" within (\d+) seconds$`, d.waitForLogLine)
+	s.Step(`^the logs of container "([^"]*)" contain "([^"]*)" since the scenario started$`, d.logsContain)
+	s.Step(`^the logs of container "([^"]*)" do not contain "([^"]*)" since the scenario started$`, d.logsDoNotContain)
+	s.Step(`^container logs are captured to directory "([^"]*)" at the end of each scenario$`, d.captureLogsToDir)
 }
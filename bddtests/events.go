@@ -0,0 +1,217 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+const eventPollInterval = 100 * time.Millisecond
+
+// ChaincodeEventSubscription records the chaincode events received for a chaincode event registration
+type ChaincodeEventSubscription struct {
+	ChannelID   string
+	ChaincodeID string
+	EventFilter string
+
+	client *event.Client
+	reg    fabApi.Registration
+	mutex  sync.RWMutex
+	events []*fabApi.CCEvent
+	done   chan struct{}
+}
+
+// NewChaincodeEventSubscription registers for chaincode events from the given chaincode whose
+// names match the given regular expression. Events are recorded until Close is called.
+func NewChaincodeEventSubscription(client *event.Client, channelID, ccID, eventFilter string) (*ChaincodeEventSubscription, error) {
+	reg, eventch, err := client.RegisterChaincodeEvent(ccID, eventFilter)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error registering for chaincode events from [%s] matching [%s]", ccID, eventFilter)
+	}
+
+	sub := &ChaincodeEventSubscription{
+		ChannelID:   channelID,
+		ChaincodeID: ccID,
+		EventFilter: eventFilter,
+		client:      client,
+		reg:         reg,
+		done:        make(chan struct{}),
+	}
+
+	go sub.listen(eventch)
+
+	return sub, nil
+}
+
+func (s *ChaincodeEventSubscription) listen(eventch <-chan *fabApi.CCEvent) {
+	for {
+		select {
+		case e, ok := <-eventch:
+			if !ok {
+				logger.Debugf("Chaincode event channel closed for [%s] on channel [%s]", s.ChaincodeID, s.ChannelID)
+				return
+			}
+			logger.Infof("Received chaincode event [%s] from [%s] in block %d on channel [%s]", e.EventName, e.ChaincodeID, e.BlockNumber, s.ChannelID)
+			s.mutex.Lock()
+			s.events = append(s.events, e)
+			s.mutex.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Events returns all of the chaincode events received so far
+func (s *ChaincodeEventSubscription) Events() []*fabApi.CCEvent {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := make([]*fabApi.CCEvent, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// Find returns the most recent event with the given name or nil if no such event was received
+func (s *ChaincodeEventSubscription) Find(eventName string) *fabApi.CCEvent {
+	events := s.Events()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].EventName == eventName {
+			return events[i]
+		}
+	}
+	return nil
+}
+
+// Close unregisters the chaincode event registration
+func (s *ChaincodeEventSubscription) Close() {
+	select {
+	case <-s.done:
+		// Already closed
+		return
+	default:
+		close(s.done)
+	}
+	s.client.Unregister(s.reg)
+}
+
+// RegisterChaincodeEvents registers for chaincode events from the given chaincode on the given channel.
+// The event filter is a regular expression which is matched against the event name.
+func (d *CommonSteps) RegisterChaincodeEvents(channelID, ccID, eventFilter string) (*ChaincodeEventSubscription, error) {
+	orgID, err := d.BDDContext.OrgIDForChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Block events (rather than filtered block events) are required in order to receive the event payload
+	client, err := d.BDDContext.OrgEventClient(orgID, USER, channelID, event.WithBlockEvents())
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create new event client")
+	}
+
+	sub, err := NewChaincodeEventSubscription(client, channelID, ccID, eventFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	d.BDDContext.AddChaincodeEventSubscription(sub)
+	return sub, nil
+}
+
+// WaitForChaincodeEvent waits until a chaincode event with the given name is received by any of the
+// current subscriptions. If payload is not nil then the event must also have the given payload.
+func (d *CommonSteps) WaitForChaincodeEvent(eventName string, payload *string, timeout time.Duration) (*fabApi.CCEvent, error) {
+	subs := d.BDDContext.ChaincodeEventSubscriptions()
+	if len(subs) == 0 {
+		return nil, errors.New("no chaincode event subscriptions - a chaincode event registration is required")
+	}
+
+	// Events with a mismatched payload are remembered so that the mismatch is only logged once per event
+	mismatched := make(map[*fabApi.CCEvent]bool)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		e, newMismatches := findChaincodeEvent(subs, eventName, payload, mismatched)
+		for _, m := range newMismatches {
+			logger.Infof("Received chaincode event [%s] but payload [%s] does not match the expected payload [%s]", eventName, m.Payload, *payload)
+		}
+		if e != nil {
+			return e, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("chaincode event [%s] not received within %s", eventName, timeout)
+		}
+		time.Sleep(eventPollInterval)
+	}
+}
+
+// findChaincodeEvent returns the first event with the given name (and the given payload, if not nil) which was
+// received by any of the given subscriptions. Events with the given name but a different payload are added to
+// mismatched and are returned as new mismatches the first time that they're found.
+func findChaincodeEvent(subs []*ChaincodeEventSubscription, eventName string, payload *string, mismatched map[*fabApi.CCEvent]bool) (*fabApi.CCEvent, []*fabApi.CCEvent) {
+	var newMismatches []*fabApi.CCEvent
+	for _, sub := range subs {
+		for _, e := range sub.Events() {
+			if e.EventName != eventName || mismatched[e] {
+				continue
+			}
+			if payload != nil && string(e.Payload) != *payload {
+				mismatched[e] = true
+				newMismatches = append(newMismatches, e)
+				continue
+			}
+			return e, newMismatches
+		}
+	}
+	return nil, newMismatches
+}
+
+func (d *CommonSteps) registerForChaincodeEvents(ccID, eventFilter, channelID string) error {
+	logger.Infof("Registering for chaincode events from [%s] matching [%s] on channel [%s]", ccID, eventFilter, channelID)
+	_, err := d.RegisterChaincodeEvents(channelID, ccID, eventFilter)
+	return err
+}
+
+func (d *CommonSteps) chaincodeEventReceived(eventName string, seconds int) error {
+	return d.waitForChaincodeEvent(eventName, nil, seconds)
+}
+
+func (d *CommonSteps) chaincodeEventWithPayloadReceived(eventName, payload string, seconds int) error {
+	payload, err := d.BDDContext.Vars().Resolve(payload)
+	if err != nil {
+		return err
+	}
+	return d.waitForChaincodeEvent(eventName, &payload, seconds)
+}
+
+func (d *CommonSteps) waitForChaincodeEvent(eventName string, payload *string, seconds int) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	e, err := d.WaitForChaincodeEvent(eventName, payload, time.Duration(seconds)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	d.BDDContext.Vars().SetResponse(string(e.Payload))
+	return nil
+}
+
+func (d *CommonSteps) setVariableFromChaincodeEvent(eventName, varName string) error {
+	for _, sub := range d.BDDContext.ChaincodeEventSubscriptions() {
+		if e := sub.Find(eventName); e != nil {
+			logger.Infof("Saving payload of chaincode event [%s] to variable %s", eventName, varName)
+			d.BDDContext.Vars().Set(varName, string(e.Payload))
+			return nil
+		}
+	}
+	return errors.Errorf("chaincode event [%s] was not received", eventName)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+	"time"
+
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindChaincodeEvent(t *testing.T) {
+	e1 := &fabApi.CCEvent{EventName: "event1", Payload: []byte("payload1")}
+	e2 := &fabApi.CCEvent{EventName: "event2", Payload: []byte("payload2")}
+	e3 := &fabApi.CCEvent{EventName: "event2", Payload: []byte("payload3")}
+
+	subs := []*ChaincodeEventSubscription{{events: []*fabApi.CCEvent{e1, e2}}, {events: []*fabApi.CCEvent{e3}}}
+
+	payload := func(p string) *string { return &p }
+
+	tests := []struct {
+		name       string
+		eventName  string
+		payload    *string
+		expected   *fabApi.CCEvent
+		mismatches []*fabApi.CCEvent
+	}{
+		{name: "Any payload", eventName: "event2", expected: e2},
+		{name: "Matching payload", eventName: "event1", payload: payload("payload1"), expected: e1},
+		{name: "Matching payload in second subscription", eventName: "event2", payload: payload("payload3"), expected: e3, mismatches: []*fabApi.CCEvent{e2}},
+		{name: "Mismatched payload", eventName: "event1", payload: payload("other"), mismatches: []*fabApi.CCEvent{e1}},
+		{name: "Not found", eventName: "event3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mismatched := make(map[*fabApi.CCEvent]bool)
+
+			e, mismatches := findChaincodeEvent(subs, tc.eventName, tc.payload, mismatched)
+			assert.Equal(t, tc.expected, e)
+			assert.Equal(t, tc.mismatches, mismatches)
+
+			// Mismatches are only reported the first time
+			e, mismatches = findChaincodeEvent(subs, tc.eventName, tc.payload, mismatched)
+			assert.Equal(t, tc.expected, e)
+			assert.Empty(t, mismatches)
+		})
+	}
+}
+
+func TestWaitForChaincodeEvent(t *testing.T) {
+	sub := &ChaincodeEventSubscription{}
+	d := &CommonSteps{BDDContext: &BDDContext{vars: NewVarStore()}}
+
+	_, err := d.WaitForChaincodeEvent("event1", nil, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no chaincode event subscriptions")
+
+	d.BDDContext.AddChaincodeEventSubscription(sub)
+
+	payload := "payload1"
+	_, err = d.WaitForChaincodeEvent("event1", &payload, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not received within")
+
+	go func() {
+		time.Sleep(2 * eventPollInterval)
+		sub.mutex.Lock()
+		defer sub.mutex.Unlock()
+		sub.events = append(sub.events,
+			&fabApi.CCEvent{EventName: "event1", Payload: []byte("other")},
+			&fabApi.CCEvent{EventName: "event1", Payload: []byte(payload)},
+		)
+	}()
+
+	e, err := d.WaitForChaincodeEvent("event1", &payload, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(e.Payload))
+}
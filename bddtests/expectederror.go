@@ -0,0 +1,304 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/DATA-DOG/godog"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	expectErrorSuffix         = ` then an error should be returned`
+	expectErrorContainsSuffix = ` then the error response should contain "([^"]*)"`
+	expectErrorStatusSuffix   = ` then the error should have status group "([^"]*)" and code "([^"]*)"`
+
+	// expectErrorArgSuffix is inserted before the trailing colon of steps with a DocString or DataTable argument
+	expectErrorArgSuffix = ` \(expecting an error\)`
+)
+
+// errorCheck checks the given error against the expected (resolved) values
+type errorCheck func(err error, expected ...string) error
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// stepWithExpectedError registers the given step along with variants of the step which expect the step to fail.
+// The variants have the same expression with one of the following suffixes:
+//
+//	then an error should be returned
+//	then the error response should contain "<text>"
+//	then the error should have status group "<group>" and code "<code>"
+//
+// The error returned by the step is saved (see VarStore.LastError) so that further assertions may be made on it.
+// Steps with a DocString or DataTable argument must use stepWithArgsAndExpectedError since the argument must be
+// the last parameter.
+func (d *CommonSteps) stepWithExpectedError(s *godog.Suite, expr string, handler interface{}) {
+	s.Step(expr, handler)
+
+	base := strings.TrimSuffix(expr, "$")
+	s.Step(base+expectErrorSuffix+"$", d.expectError(handler, 0, checkErrorReturned))
+	s.Step(base+expectErrorContainsSuffix+"$", d.expectError(handler, 1, checkErrorContains))
+	s.Step(base+expectErrorStatusSuffix+"$", d.expectError(handler, 2, checkErrorStatus))
+}
+
+// stepWithArgsAndExpectedError registers the given step, which ends with a colon followed by a DocString or
+// DataTable argument, along with a variant of the step which expects the step to fail. The variant has
+// "(expecting an error)" before the colon, e.g.
+//
+//	client invokes chaincode "cc" on the "ch" channel with args (expecting an error):
+//
+// The error returned by the step is saved so that it may be checked by subsequent steps,
+// e.g. the error response should contain "<text>".
+func (d *CommonSteps) stepWithArgsAndExpectedError(s *godog.Suite, expr string, handler interface{}) {
+	s.Step(expr, handler)
+	s.Step(expectErrorArgsExpr(expr), d.expectError(handler, 0, checkErrorReturned))
+}
+
+// expectErrorArgsExpr returns the expression of the expected error variant of the given step expression
+func expectErrorArgsExpr(expr string) string {
+	if !strings.HasSuffix(expr, ":$") {
+		panic(errors.Errorf("step with DocString or DataTable argument must end with a colon: %s", expr))
+	}
+	return strings.TrimSuffix(expr, ":$") + expectErrorArgSuffix + ":$"
+}
+
+// expectError returns a step handler which accepts the parameters of the given handler followed by numExpected
+// string parameters. The handler is invoked and the returned error is checked against the expected values.
+func (d *CommonSteps) expectError(handler interface{}, numExpected int, check errorCheck) interface{} {
+	fn := reflect.ValueOf(handler)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func || fnType.IsVariadic() || fnType.NumOut() != 1 || fnType.Out(0) != errorType {
+		panic(errors.Errorf("invalid step handler: %s", fnType))
+	}
+
+	var in []reflect.Type
+	for i := 0; i < fnType.NumIn(); i++ {
+		in = append(in, fnType.In(i))
+	}
+	for i := 0; i < numExpected; i++ {
+		in = append(in, reflect.TypeOf(""))
+	}
+
+	wrapperType := reflect.FuncOf(in, []reflect.Type{errorType}, false)
+
+	return reflect.MakeFunc(wrapperType, func(args []reflect.Value) []reflect.Value {
+		var expected []string
+		for _, arg := range args[fnType.NumIn():] {
+			expected = append(expected, arg.String())
+		}
+
+		d.BDDContext.Vars().SetLastError(nil)
+
+		var stepErr error
+		if result := fn.Call(args[:fnType.NumIn()])[0]; !result.IsNil() {
+			stepErr = result.Interface().(error)
+		}
+
+		err := d.checkExpectedError(stepErr, expected, check)
+		return []reflect.Value{reflect.ValueOf(&err).Elem()}
+	}).Interface()
+}
+
+func (d *CommonSteps) checkExpectedError(stepErr error, expected []string, check errorCheck) error {
+	if stepErr == nil {
+		return errors.New("expecting an error but the request succeeded")
+	}
+
+	logger.Infof("Step returned expected error: %s", stepErr)
+	d.BDDContext.Vars().SetLastError(stepErr)
+
+	resolved, err := d.BDDContext.Vars().ResolveAll(expected)
+	if err != nil {
+		return err
+	}
+
+	return check(stepErr, resolved...)
+}
+
+func (d *CommonSteps) requireLastError() (error, error) {
+	err := d.BDDContext.Vars().LastError()
+	if err == nil {
+		return nil, errors.New("no error was returned by a previous step")
+	}
+	return err, nil
+}
+
+func (d *CommonSteps) lastErrorHasStatus(group, code string) error {
+	return d.checkLastError(checkErrorStatus, group, code)
+}
+
+func (d *CommonSteps) lastErrorContains(expected string) error {
+	return d.checkLastError(checkErrorContains, expected)
+}
+
+func (d *CommonSteps) lastErrorMatches(expr string) error {
+	return d.checkLastError(checkErrorMatches, expr)
+}
+
+func (d *CommonSteps) checkLastError(check errorCheck, expected ...string) error {
+	lastErr, err := d.requireLastError()
+	if err != nil {
+		return err
+	}
+
+	resolved, err := d.BDDContext.Vars().ResolveAll(expected)
+	if err != nil {
+		return err
+	}
+
+	return check(lastErr, resolved...)
+}
+
+func (d *CommonSteps) setVariableFromLastError(varName string) error {
+	lastErr, err := d.requireLastError()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Saving error response to variable %s: %s", varName, lastErr)
+	d.BDDContext.Vars().Set(varName, lastErr.Error())
+	return nil
+}
+
+func (d *CommonSteps) setVariableFromChaincodeErrorMessage(varName string) error {
+	msg, err := d.chaincodeErrorMessage()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Saving chaincode error message to variable %s: %s", varName, msg)
+	d.BDDContext.Vars().Set(varName, msg)
+	return nil
+}
+
+func (d *CommonSteps) jsonPathOfChaincodeErrorMessageEquals(path, expected string) error {
+	msg, err := d.chaincodeErrorMessage()
+	if err != nil {
+		return err
+	}
+
+	expected, err = d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+
+	r := gjson.Get(msg, path)
+	logger.Infof("Path [%s] of JSON %s resolves to %s", path, msg, r.String())
+	if r.String() == expected {
+		return nil
+	}
+	return errors.Errorf("JSON path resolves to [%s] which is not the expected value [%s]", r.String(), expected)
+}
+
+// chaincodeErrorMessage returns the message returned by the chaincode in the last error
+func (d *CommonSteps) chaincodeErrorMessage() (string, error) {
+	lastErr, err := d.requireLastError()
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := findStatus(lastErr, func(s *status.Status) bool { return s.Group == status.ChaincodeStatus })
+	if !ok {
+		return "", errors.WithMessage(lastErr, "the error does not contain a chaincode status")
+	}
+	return s.Message, nil
+}
+
+func checkErrorReturned(error, ...string) error {
+	return nil
+}
+
+func checkErrorContains(err error, expected ...string) error {
+	if !strings.Contains(err.Error(), expected[0]) {
+		return errors.Errorf("expecting error [%s] but got [%s]", expected[0], err)
+	}
+	return nil
+}
+
+func checkErrorMatches(err error, expected ...string) error {
+	return checkMatches("error", err.Error(), expected[0])
+}
+
+// checkErrorStatus checks that the error (or, in the case of multiple errors, one of the errors) has the given
+// status group and code. The group is matched against the SDK status group name ignoring case, spaces and an
+// optional "status" suffix, e.g. "Endorser Client", "chaincode" or "Event Server Status". The code may either be
+// numeric or the name of the code within the group, e.g. "500", "ENDORSEMENT_MISMATCH" or "MVCC_READ_CONFLICT".
+func checkErrorStatus(err error, expected ...string) error {
+	group, code := expected[0], expected[1]
+
+	if _, ok := findStatus(err, func(s *status.Status) bool { return statusMatches(s, group, code) }); !ok {
+		return errors.Errorf("expecting error with status group [%s] and code [%s] but got [%s]", group, code, err)
+	}
+	return nil
+}
+
+// findStatus returns the first status within the given error for which match returns true.
+// If the error contains multiple errors then each of the errors is searched.
+func findStatus(err error, match func(s *status.Status) bool) (*status.Status, bool) {
+	s, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+
+	if match(s) {
+		return s, true
+	}
+
+	for _, detail := range s.Details {
+		if detailErr, ok := detail.(error); ok {
+			if ds, ok := findStatus(detailErr, match); ok {
+				return ds, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func statusMatches(s *status.Status, group, code string) bool {
+	groupName := normalizeStatusGroup(s.Group.String())
+	expectedGroup := normalizeStatusGroup(group)
+	if expectedGroup != groupName && expectedGroup != strings.TrimSuffix(groupName, "status") {
+		return false
+	}
+
+	if c, err := strconv.ParseInt(code, 10, 32); err == nil {
+		return int32(c) == s.Code
+	}
+
+	return strings.EqualFold(code, statusCodeName(s))
+}
+
+func normalizeStatusGroup(group string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.ToLower(group))
+}
+
+// statusCodeName returns the name of the status code according to the status group
+func statusCodeName(s *status.Status) string {
+	switch s.Group {
+	case status.GRPCTransportStatus:
+		return status.ToGRPCStatusCode(s.Code).String()
+	case status.EndorserServerStatus, status.OrdererServerStatus:
+		return status.ToFabricCommonStatusCode(s.Code).String()
+	case status.EventServerStatus:
+		return status.ToTransactionValidationCode(s.Code).String()
+	case status.EndorserClientStatus, status.OrdererClientStatus, status.ClientStatus:
+		return status.ToSDKStatusCode(s.Code).String()
+	default:
+		return strconv.Itoa(int(s.Code))
+	}
+}
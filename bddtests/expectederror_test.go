@@ -0,0 +1,96 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/godog/gherkin"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckErrorStatus(t *testing.T) {
+	ccErr := errors.WithMessage(status.New(status.ChaincodeStatus, 500, "some failure", nil), "QueryChaincode return error")
+	mismatch := status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(), "mismatch", nil)
+	mvcc := status.New(status.EventServerStatus, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), "invalid transaction", nil)
+
+	assert.NoError(t, checkErrorStatus(ccErr, "Chaincode status", "500"))
+	assert.NoError(t, checkErrorStatus(ccErr, "chaincode", "500"))
+	assert.Error(t, checkErrorStatus(ccErr, "chaincode", "403"))
+	assert.Error(t, checkErrorStatus(ccErr, "Endorser Client", "500"))
+
+	assert.NoError(t, checkErrorStatus(mismatch, "Endorser Client Status", "ENDORSEMENT_MISMATCH"))
+	assert.NoError(t, checkErrorStatus(mismatch, "endorser client", "3"))
+	assert.NoError(t, checkErrorStatus(mvcc, "Event Server", "mvcc_read_conflict"))
+
+	assert.NoError(t, checkErrorStatus(multi.Errors{mismatch, ccErr}, "chaincode", "500"))
+	assert.NoError(t, checkErrorStatus(multi.Errors{mismatch, ccErr}, "client", "MULTIPLE_ERRORS"))
+
+	assert.Error(t, checkErrorStatus(errors.New("some error"), "chaincode", "500"))
+}
+
+func TestExpectError(t *testing.T) {
+	d := &CommonSteps{BDDContext: &BDDContext{vars: NewVarStore()}}
+	d.BDDContext.Vars().Set("code", "500")
+
+	ccErr := status.New(status.ChaincodeStatus, 500, `{"reason":"not found"}`, nil)
+	step := func(arg string) error {
+		if arg == "fail" {
+			return ccErr
+		}
+		return nil
+	}
+
+	handler, ok := d.expectError(step, 2, checkErrorStatus).(func(string, string, string) error)
+	require.True(t, ok)
+
+	require.NoError(t, handler("fail", "chaincode", "${code}"))
+	assert.Equal(t, ccErr, d.BDDContext.Vars().LastError())
+
+	msg, err := d.chaincodeErrorMessage()
+	require.NoError(t, err)
+	assert.Equal(t, `{"reason":"not found"}`, msg)
+	assert.NoError(t, d.jsonPathOfChaincodeErrorMessageEquals("reason", "not found"))
+	assert.NoError(t, d.lastErrorMatches(`Code: \(500\)`))
+
+	assert.Error(t, handler("succeed", "chaincode", "500"))
+	assert.Nil(t, d.BDDContext.Vars().LastError())
+	assert.Error(t, d.lastErrorContains("500"))
+}
+
+func TestExpectErrorWithArgs(t *testing.T) {
+	d := &CommonSteps{BDDContext: &BDDContext{vars: NewVarStore()}}
+
+	expr := expectErrorArgsExpr(`^client invokes chaincode "([^"]*)" on the "([^"]*)" channel with args:$`)
+	assert.Equal(t, `^client invokes chaincode "([^"]*)" on the "([^"]*)" channel with args \(expecting an error\):$`, expr)
+	assert.True(t, regexp.MustCompile(expr).MatchString(`client invokes chaincode "cc1" on the "mychannel" channel with args (expecting an error):`))
+	assert.Panics(t, func() { expectErrorArgsExpr(`^client invokes chaincode "([^"]*)"$`) })
+
+	ccErr := status.New(status.ChaincodeStatus, 500, "some failure", nil)
+	step := func(ccID string, doc *gherkin.DocString) error {
+		if DocStringArgs(doc)[0] == "fail" {
+			return ccErr
+		}
+		return nil
+	}
+
+	handler, ok := d.expectError(step, 0, checkErrorReturned).(func(string, *gherkin.DocString) error)
+	require.True(t, ok)
+
+	require.NoError(t, handler("cc1", &gherkin.DocString{Content: "fail, arg1"}))
+	assert.Equal(t, ccErr, d.BDDContext.Vars().LastError())
+	assert.NoError(t, d.lastErrorContains("some failure"))
+
+	assert.Error(t, handler("cc1", &gherkin.DocString{Content: "succeed, arg1"}))
+	assert.Nil(t, d.BDDContext.Vars().LastError())
+}
@@ -14,10 +14,15 @@ import (
 )
 
 // FabCLI is used to invoke the Fabric CLI command-line tool
+//
+// Deprecated: blocks and channel info are now queried with the SDK ledger client (see BDDContext.OrgLedgerClient).
+// FabCLI is no longer used by the steps in this package and will be removed in a future release.
 type FabCLI struct {
 }
 
 // NewFabCLI returns a new FabCLI
+//
+// Deprecated: see FabCLI.
 func NewFabCLI() *FabCLI {
 	return &FabCLI{}
 }
@@ -0,0 +1,147 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	implicitCollectionPrefix = "_implicit_org_"
+
+	// collectionVar is the name of the variable which holds the name of the implicit collection
+	// when resolving the args of the implicit collection steps, e.g. "putprivate,${implicit_collection},key1"
+	collectionVar = "implicit_collection"
+)
+
+// ImplicitCollectionName returns the name of the implicit private data collection of the org with the given MSP ID
+func ImplicitCollectionName(mspID string) string {
+	return implicitCollectionPrefix + mspID
+}
+
+// ImplicitOrgCollection returns the name of the implicit private data collection of the given org.
+// The MSP ID of the org is resolved from the network config.
+func (d *CommonSteps) ImplicitOrgCollection(orgID string) (string, error) {
+	mspID, err := d.BDDContext.OrgMSPID(orgID)
+	if err != nil {
+		return "", err
+	}
+	return ImplicitCollectionName(mspID), nil
+}
+
+// resolveArgsForImplicitCollection resolves the given comma-separated args. The name of the implicit collection
+// of the given org is available to the args as the variable ${implicit_collection}.
+func (d *CommonSteps) resolveArgsForImplicitCollection(args, orgID string) ([]string, error) {
+	coll, err := d.ImplicitOrgCollection(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveArgsWithCollection(d.BDDContext.Vars().Vars(), coll, args)
+}
+
+// resolveArgsWithCollection resolves the given comma-separated args using the given variables along with the
+// collection variable. An error is returned if the collection variable is already defined.
+func resolveArgsWithCollection(vars map[string]string, coll, args string) ([]string, error) {
+	if _, ok := vars[collectionVar]; ok {
+		return nil, errors.Errorf("variable [%s] is reserved for the name of the implicit collection", collectionVar)
+	}
+
+	vars[collectionVar] = coll
+
+	return ResolveAll(vars, SplitArgs(args))
+}
+
+func (d *CommonSteps) setVariableFromImplicitCollection(orgID, varName string) error {
+	coll, err := d.ImplicitOrgCollection(orgID)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Saving implicit collection name %s of org %s to variable %s", coll, orgID, varName)
+	d.BDDContext.Vars().Set(varName, coll)
+	return nil
+}
+
+func (d *CommonSteps) invokeCCWithTransientMapOnImplicitCollection(ccID, args, name, collOrgID, channelID string) error {
+	return d.invokeCCWithTransientMapOnImplicitCollectionAsUser(ccID, args, name, collOrgID, channelID, USER, "")
+}
+
+func (d *CommonSteps) invokeCCWithTransientMapOnImplicitCollectionAsUser(ccID, args, name, collOrgID, channelID, userType, orgID string) error {
+	argArr, err := d.resolveArgsForImplicitCollection(args, collOrgID)
+	if err != nil {
+		return err
+	}
+	return d.invokeCCWithArgArrayAndTransientMap(ccID, argArr, name, "", channelID, userType, orgID)
+}
+
+func (d *CommonSteps) queryCCWithTransientMapOnImplicitCollection(ccID, args, name, collOrgID, channelID string) error {
+	return d.queryCCWithTransientMapOnImplicitCollectionAsUser(ccID, args, name, collOrgID, channelID, ADMIN, "")
+}
+
+func (d *CommonSteps) queryCCWithTransientMapOnImplicitCollectionAsUser(ccID, args, name, collOrgID, channelID, userType, orgID string) error {
+	argArr, err := d.resolveArgsForImplicitCollection(args, collOrgID)
+	if err != nil {
+		return err
+	}
+	return d.queryCCWithArgArrayAndTransientMap(ccID, argArr, name, "", channelID, userType, orgID)
+}
+
+func (d *CommonSteps) privateDataIsPresentOnlyOnImplicitCollectionMembers(ccID, args, orgID, channelID string) error {
+	mspID, err := d.BDDContext.OrgMSPID(orgID)
+	if err != nil {
+		return err
+	}
+
+	argArr, err := d.resolveArgsForImplicitCollection(args, orgID)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateDataWithArgArray(ccID, argArr, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	return checkPrivateData(results, func(peer *PeerConfig) bool { return peer.MspID == mspID })
+}
+
+func (d *CommonSteps) privateDataHashIsPresentOnAllPeersForImplicitCollection(ccID, args, orgID, channelID string) error {
+	argArr, err := d.resolveArgsForImplicitCollection(args, orgID)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateDataWithArgArray(ccID, argArr, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	return checkPrivateDataHash(results, nil)
+}
+
+func (d *CommonSteps) privateDataHashOfValueIsPresentOnAllPeersForImplicitCollection(ccID, args, orgID, value, channelID string) error {
+	value, err := d.BDDContext.Vars().Resolve(value)
+	if err != nil {
+		return err
+	}
+
+	argArr, err := d.resolveArgsForImplicitCollection(args, orgID)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateDataWithArgArray(ccID, argArr, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(value))
+	return checkPrivateDataHash(results, hash[:])
+}
@@ -0,0 +1,283 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+const ledgerCheckRetryInterval = time.Second
+
+// queryChannelInfoFromAllPeers queries the blockchain info from each peer on the given channel.
+// The returned map is keyed by peer ID.
+func (d *CommonSteps) queryChannelInfoFromAllPeers(channelID string) (map[string]*queryInfoResponse, error) {
+	peers := d.BDDContext.PeersByChannel(channelID)
+	if len(peers) == 0 {
+		return nil, errors.Errorf("no peers found for channel [%s]", channelID)
+	}
+
+	infos := make(map[string]*queryInfoResponse)
+	for _, pconfig := range peers {
+		ledgerClient, target, err := d.peerLedgerClient(channelID, pconfig)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := ledgerClient.QueryInfo(ledger.WithTargets(target))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error querying channel info from peer [%s]", pconfig.PeerID)
+		}
+
+		infos[pconfig.PeerID] = newQueryInfoResponse(resp)
+	}
+
+	return infos, nil
+}
+
+// CheckLedgerConsistency verifies that all peers on the given channel report the same block height and
+// current block hash. If they don't then an error is returned which describes where the peers diverge.
+func (d *CommonSteps) CheckLedgerConsistency(channelID string) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	infos, err := d.queryChannelInfoFromAllPeers(channelID)
+	if err != nil {
+		return err
+	}
+
+	infosBytes, err := json.Marshal(infos)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling channel info")
+	}
+	d.BDDContext.Vars().SetResponse(string(infosBytes))
+
+	if isConsistent(infos) {
+		return nil
+	}
+
+	return d.divergenceError(channelID, infos)
+}
+
+func (d *CommonSteps) divergenceError(channelID string, infos map[string]*queryInfoResponse) error {
+	var peerIDs []string
+	minHeight := -1
+	for peerID, info := range infos {
+		peerIDs = append(peerIDs, peerID)
+		height, err := strconv.Atoi(info.Height)
+		if err != nil {
+			return errors.Wrapf(err, "invalid height [%s] for peer [%s]", info.Height, peerID)
+		}
+		if minHeight < 0 || height < minHeight {
+			minHeight = height
+		}
+	}
+	sort.Strings(peerIDs)
+
+	var details []string
+	for _, peerID := range peerIDs {
+		details = append(details, fmt.Sprintf("%s (height %s, current block hash %s)", peerID, infos[peerID].Height, infos[peerID].CurrentBlockHash))
+	}
+
+	if minHeight <= 0 {
+		return errors.Errorf("ledgers are inconsistent on channel [%s]: %s", channelID, strings.Join(details, ", "))
+	}
+
+	// Compare the highest block that all peers have committed in order to determine
+	// whether the peers have forked or whether some peers are simply lagging
+	topBlockNum := uint64(minHeight - 1)
+	topHashes, err := d.blockHashesFromAllPeers(channelID, topBlockNum)
+	if err != nil {
+		return err
+	}
+
+	if len(distinctHashes(topHashes)) == 1 {
+		return errors.Errorf("ledgers are consistent on channel [%s] up to block %d but heights differ: %s", channelID, topBlockNum, strings.Join(details, ", "))
+	}
+
+	blockNum, hashes, err := firstDivergentBlock(topBlockNum, topHashes, func(blockNum uint64) (map[string]string, error) {
+		return d.blockHashesFromAllPeers(channelID, blockNum)
+	})
+	if err != nil {
+		return err
+	}
+
+	majority, ok := majorityHash(hashes)
+	if !ok {
+		return errors.Errorf("ledgers diverge on channel [%s] at block %d and there is no majority - peers by block hash: %s. Peer info: %s", channelID, blockNum, peersByHash(hashes), strings.Join(details, ", "))
+	}
+
+	return errors.Errorf("ledgers diverge on channel [%s] at block %d - peers with a different block: %s. Peer info: %s", channelID, blockNum, strings.Join(divergedPeers(hashes, majority), ", "), strings.Join(details, ", "))
+}
+
+// blockHashesFromAllPeers queries the given block from each peer on the channel and returns the block hashes
+// (the previous hash and data hash of the block) keyed by peer ID
+func (d *CommonSteps) blockHashesFromAllPeers(channelID string, blockNum uint64) (map[string]string, error) {
+	blockHashes := make(map[string]string)
+	for _, pconfig := range d.BDDContext.PeersByChannel(channelID) {
+		ledgerClient, target, err := d.peerLedgerClient(channelID, pconfig)
+		if err != nil {
+			return nil, err
+		}
+
+		block, err := ledgerClient.QueryBlock(blockNum, ledger.WithTargets(target))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error querying block %d from peer [%s]", blockNum, pconfig.PeerID)
+		}
+
+		decodedBlock, err := DecodeBlock(block)
+		if err != nil {
+			return nil, err
+		}
+
+		blockHashes[pconfig.PeerID] = decodedBlock.PreviousHash + ":" + decodedBlock.DataHash
+	}
+
+	return blockHashes, nil
+}
+
+// firstDivergentBlock performs a binary search for the first block at which the peers' block hashes differ.
+// The peers are known to differ at topBlockNum (with the given hashes). Since each block includes the hash of
+// the previous block, once the peers' ledgers diverge they differ at every subsequent block. The number of the
+// first divergent block is returned along with the peers' hashes at that block.
+func firstDivergentBlock(topBlockNum uint64, topHashes map[string]string, getHashes func(blockNum uint64) (map[string]string, error)) (uint64, map[string]string, error) {
+	lo, hi := uint64(0), topBlockNum
+	hashes := topHashes
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		midHashes, err := getHashes(mid)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if len(distinctHashes(midHashes)) == 1 {
+			lo = mid + 1
+		} else {
+			hi = mid
+			hashes = midHashes
+		}
+	}
+
+	return hi, hashes, nil
+}
+
+// majorityHash returns the block hash reported by the most peers. False is returned if
+// two or more hashes are reported by the same (highest) number of peers.
+func majorityHash(hashes map[string]string) (string, bool) {
+	hashCount := make(map[string]int)
+	for _, hash := range hashes {
+		hashCount[hash]++
+	}
+
+	var majority string
+	maxCount := 0
+	tie := false
+	for _, hash := range distinctHashes(hashes) {
+		switch count := hashCount[hash]; {
+		case count > maxCount:
+			majority, maxCount, tie = hash, count, false
+		case count == maxCount:
+			tie = true
+		}
+	}
+
+	return majority, !tie
+}
+
+// divergedPeers returns the sorted IDs of the peers whose block hash differs from the majority hash
+func divergedPeers(hashes map[string]string, majority string) []string {
+	var diverged []string
+	for peerID, hash := range hashes {
+		if hash != majority {
+			diverged = append(diverged, peerID)
+		}
+	}
+	sort.Strings(diverged)
+	return diverged
+}
+
+// distinctHashes returns the sorted, distinct block hashes
+func distinctHashes(hashes map[string]string) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, hash := range hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			distinct = append(distinct, hash)
+		}
+	}
+	sort.Strings(distinct)
+	return distinct
+}
+
+func peersByHash(hashes map[string]string) string {
+	var groups []string
+	for _, hash := range distinctHashes(hashes) {
+		var peerIDs []string
+		for peerID, h := range hashes {
+			if h == hash {
+				peerIDs = append(peerIDs, peerID)
+			}
+		}
+		sort.Strings(peerIDs)
+		groups = append(groups, fmt.Sprintf("%s: %s", hash, strings.Join(peerIDs, ", ")))
+	}
+	return strings.Join(groups, "; ")
+}
+
+func (d *CommonSteps) peerLedgerClient(channelID string, pconfig *PeerConfig) (*ledger.Client, fabApi.Peer, error) {
+	target, err := d.BDDContext.OrgUserContext(pconfig.OrgID, ADMIN).InfraProvider().CreatePeerFromConfig(&fabApi.NetworkPeer{PeerConfig: pconfig.Config})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "NewPeer failed")
+	}
+
+	ledgerClient, err := d.BDDContext.OrgLedgerClient(pconfig.OrgID, ADMIN, channelID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to create new ledger client")
+	}
+
+	return ledgerClient, target, nil
+}
+
+func isConsistent(infos map[string]*queryInfoResponse) bool {
+	var expected *queryInfoResponse
+	for _, info := range infos {
+		if expected == nil {
+			expected = info
+			continue
+		}
+		if info.Height != expected.Height || info.CurrentBlockHash != expected.CurrentBlockHash {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *CommonSteps) ledgersAreConsistent(channelID string) error {
+	return d.CheckLedgerConsistency(channelID)
+}
+
+func (d *CommonSteps) ledgersAreConsistentWithin(channelID string, seconds int) error {
+	deadline := time.Now().Add(time.Duration(seconds) * time.Second)
+	for {
+		err := d.CheckLedgerConsistency(channelID)
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+
+		logger.Infof("Ledgers are not yet consistent on channel [%s]: %s. Retrying in %s...", channelID, err, ledgerCheckRetryInterval)
+		time.Sleep(ledgerCheckRetryInterval)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstDivergentBlock(t *testing.T) {
+	const forkedAt = 6
+
+	// peer2 forks at block 6 so it has a different hash from then on
+	getHashes := func(blockNum uint64) (map[string]string, error) {
+		hashes := map[string]string{"peer0": "a", "peer1": "a", "peer2": "a"}
+		if blockNum >= forkedAt {
+			hashes["peer2"] = "b"
+		}
+		return hashes, nil
+	}
+
+	for _, top := range []uint64{forkedAt, forkedAt + 1, 20} {
+		topHashes, err := getHashes(top)
+		require.NoError(t, err)
+
+		blockNum, hashes, err := firstDivergentBlock(top, topHashes, getHashes)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(forkedAt), blockNum)
+		assert.Equal(t, map[string]string{"peer0": "a", "peer1": "a", "peer2": "b"}, hashes)
+	}
+
+	t.Run("Query error", func(t *testing.T) {
+		_, _, err := firstDivergentBlock(10, map[string]string{"peer0": "a", "peer1": "b"}, func(uint64) (map[string]string, error) {
+			return nil, errors.New("query error")
+		})
+		assert.EqualError(t, err, "query error")
+	})
+}
+
+func TestMajorityHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		hashes   map[string]string
+		majority string
+		diverged []string
+		ok       bool
+	}{
+		{name: "Majority", hashes: map[string]string{"peer0": "a", "peer1": "b", "peer2": "b"}, majority: "b", diverged: []string{"peer0"}, ok: true},
+		{name: "Tie", hashes: map[string]string{"peer0": "a", "peer1": "b", "peer2": "a", "peer3": "b"}, ok: false},
+		{name: "Plurality", hashes: map[string]string{"peer0": "a", "peer1": "b", "peer2": "c", "peer3": "c"}, majority: "c", diverged: []string{"peer0", "peer1"}, ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Run several times since map iteration order is random
+			for i := 0; i < 10; i++ {
+				majority, ok := majorityHash(tc.hashes)
+				require.Equal(t, tc.ok, ok)
+				if ok {
+					require.Equal(t, tc.majority, majority)
+					require.Equal(t, tc.diverged, divergedPeers(tc.hashes, majority))
+				}
+			}
+		})
+	}
+
+	assert.Equal(t, "a: peer0, peer2; b: peer1", peersByHash(map[string]string{"peer0": "a", "peer1": "b", "peer2": "a"}))
+}
@@ -0,0 +1,610 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/gopackager"
+	fabChannel "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	lifecycleCC = "_lifecycle"
+
+	installFuncName            = "InstallChaincode"
+	approveFuncName            = "ApproveChaincodeDefinitionForMyOrg"
+	checkCommitReadinessFunc   = "CheckCommitReadiness"
+	commitFuncName             = "CommitChaincodeDefinition"
+	queryDefinitionFuncName    = "QueryChaincodeDefinition"
+	defaultEndorsementPlugin   = "escc"
+	defaultValidationPlugin    = "vscc"
+	lifecycleInstallTimeout    = 5 * time.Minute
+	lifecycleTransactTimeout   = 2 * time.Minute
+	lifecyclePackageMetadata   = "metadata.json"
+	lifecyclePackageCode       = "code.tar.gz"
+	lifecyclePackageGolangType = "golang"
+)
+
+// ChaincodePackage is a chaincode package in the format expected by the new (_lifecycle) chaincode lifecycle
+type ChaincodePackage struct {
+	Label string
+	Bytes []byte
+}
+
+// PackageID returns the ID of the package as computed by the peer, i.e. <label>:<hex(sha256(package))>
+func (p *ChaincodePackage) PackageID() string {
+	hash := sha256.Sum256(p.Bytes)
+	return fmt.Sprintf("%s:%s", p.Label, hex.EncodeToString(hash[:]))
+}
+
+// ChaincodeDefinition contains the parameters of a chaincode definition
+// which is approved and committed using the new chaincode lifecycle
+type ChaincodeDefinition struct {
+	Name            string
+	Version         string
+	Sequence        int64
+	PackageID       string
+	Policy          string
+	CollectionNames []string
+	InitRequired    bool
+}
+
+type packageMetadata struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// NewChaincodePackage packages the Go chaincode at the given path (relative to goPath/src)
+// into a package that may be installed using the new chaincode lifecycle
+func NewChaincodePackage(label, ccPath, goPath string) (*ChaincodePackage, error) {
+	if label == "" {
+		return nil, errors.New("chaincode package label must be provided")
+	}
+
+	ccPkg, err := gopackager.NewCCPackage(ccPath, goPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error packaging chaincode from path [%s]", ccPath)
+	}
+
+	metadata, err := json.Marshal(&packageMetadata{Path: ccPath, Type: lifecyclePackageGolangType, Label: label})
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling package metadata")
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	if err := writeTarEntry(tw, lifecyclePackageMetadata, metadata); err != nil {
+		return nil, err
+	}
+	if err := writeTarEntry(tw, lifecyclePackageCode, ccPkg.Code); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "error closing tar writer")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrap(err, "error closing gzip writer")
+	}
+
+	return &ChaincodePackage{Label: label, Bytes: buf.Bytes()}, nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, contents []byte) error {
+	hdr := &tar.Header{
+		Name: name,
+		Mode: 0100644,
+		Size: int64(len(contents)),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "error writing tar header for [%s]", name)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return errors.Wrapf(err, "error writing tar entry [%s]", name)
+	}
+	return nil
+}
+
+// PackageChaincode packages the chaincode at the given path and registers the package with the given label
+func (d *CommonSteps) PackageChaincode(ccType, label, ccPath string) (*ChaincodePackage, error) {
+	pkg, err := NewChaincodePackage(label, ccPath, d.getDeployPath(ccType))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Created chaincode package [%s] from path [%s]", pkg.PackageID(), ccPath)
+	d.BDDContext.AddChaincodePackage(pkg)
+	return pkg, nil
+}
+
+// InstallChaincodePackage installs the chaincode package with the given label to all local peers
+// in the given orgs (or all orgs if orgIDs is empty). The package ID is returned.
+func (d *CommonSteps) InstallChaincodePackage(label, orgIDs, blackListRegex string) (string, error) {
+	return d.InstallChaincodePackageAsUser(label, orgIDs, blackListRegex, "", ADMIN)
+}
+
+// InstallChaincodePackageAsUser installs the chaincode package with the given label to all local peers in the
+// given orgs (or all orgs if orgIDs is empty) as the given user of the given org. If the org is empty then the
+// given user of each of the orgs is used. The package ID is returned.
+func (d *CommonSteps) InstallChaincodePackageAsUser(label, orgIDs, blackListRegex, userOrgID, userType string) (string, error) {
+	pkg := d.BDDContext.ChaincodePackage(label)
+	if pkg == nil {
+		return "", errors.Errorf("chaincode package [%s] not found", label)
+	}
+
+	var oIDs []string
+	if orgIDs != "" {
+		oIDs = strings.Split(orgIDs, ",")
+	} else {
+		oIDs = d.BDDContext.orgs
+	}
+
+	argBytes, err := proto.Marshal(&lb.InstallChaincodeArgs{ChaincodeInstallPackage: pkg.Bytes})
+	if err != nil {
+		return "", errors.Wrap(err, "error marshalling install chaincode args")
+	}
+
+	for _, orgID := range oIDs {
+		targets, err := d.getLocalTargets(orgID, blackListRegex)
+		if err != nil {
+			return "", err
+		}
+		if len(targets) == 0 {
+			return "", errors.Errorf("no targets for chaincode package [%s]", label)
+		}
+
+		clientOrgID := userOrgID
+		if clientOrgID == "" {
+			clientOrgID = orgID
+		}
+
+		for _, target := range targets {
+			if err := d.installChaincodePackageOnPeer(clientOrgID, userType, target, pkg, argBytes); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return pkg.PackageID(), nil
+}
+
+func (d *CommonSteps) installChaincodePackageOnPeer(orgID, userType, url string, pkg *ChaincodePackage, argBytes []byte) error {
+	pconfig := d.BDDContext.PeerConfigForURL(url)
+	if pconfig == nil {
+		return errors.Errorf("peer config not found for URL [%s]", url)
+	}
+
+	orgContext := d.BDDContext.OrgUserContext(orgID, userType)
+	if orgContext == nil {
+		return errors.Errorf("unable to get context for user [%s] of org [%s]", userType, orgID)
+	}
+
+	target, err := orgContext.InfraProvider().CreatePeerFromConfig(&fabApi.NetworkPeer{PeerConfig: pconfig.Config})
+	if err != nil {
+		return errors.WithMessage(err, "NewPeer failed")
+	}
+
+	logger.Infof("... installing chaincode package [%s] to peer [%s]", pkg.Label, url)
+
+	reqCtx, cancel := context.NewRequest(orgContext, context.WithTimeout(lifecycleInstallTimeout))
+	defer cancel()
+
+	transactor, err := fabChannel.NewTransactor(reqCtx, chconfig.NewChannelCfg(""))
+	if err != nil {
+		return errors.WithMessage(err, "error creating transactor")
+	}
+
+	responses, _, err := createAndSendTransactionProposal(transactor,
+		&invoke.Request{ChaincodeID: lifecycleCC, Fcn: installFuncName, Args: [][]byte{argBytes}},
+		peer.PeersToTxnProcessors([]fabApi.Peer{target}),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "chaincode already successfully installed") {
+			logger.Infof("Chaincode package [%s] is already installed on peer [%s]", pkg.Label, url)
+			return nil
+		}
+		return errors.WithMessagef(err, "error installing chaincode package [%s] on peer [%s]", pkg.Label, url)
+	}
+
+	for _, r := range responses {
+		result := &lb.InstallChaincodeResult{}
+		if err := proto.Unmarshal(r.ProposalResponse.GetResponse().Payload, result); err != nil {
+			return errors.Wrap(err, "error unmarshalling install chaincode result")
+		}
+		if result.PackageId != pkg.PackageID() {
+			return errors.Errorf("package ID [%s] returned from peer [%s] does not match the expected package ID [%s]", result.PackageId, url, pkg.PackageID())
+		}
+	}
+
+	return nil
+}
+
+// ApproveChaincodeDefinition approves the given chaincode definition on behalf of each of the given orgs
+// (or all orgs on the channel if orgIDs is empty)
+func (d *CommonSteps) ApproveChaincodeDefinition(channelID, orgIDs string, def *ChaincodeDefinition) error {
+	validationParam, collections, err := d.definitionPolicies(channelID, def)
+	if err != nil {
+		return err
+	}
+
+	args := &lb.ApproveChaincodeDefinitionForMyOrgArgs{
+		Name:                def.Name,
+		Version:             def.Version,
+		Sequence:            def.Sequence,
+		EndorsementPlugin:   defaultEndorsementPlugin,
+		ValidationPlugin:    defaultValidationPlugin,
+		ValidationParameter: validationParam,
+		Collections:         collections,
+		InitRequired:        def.InitRequired,
+	}
+
+	if def.PackageID != "" {
+		args.Source = &lb.ChaincodeSource{
+			Type: &lb.ChaincodeSource_LocalPackage{
+				LocalPackage: &lb.ChaincodeSource_Local{PackageId: def.PackageID},
+			},
+		}
+	} else {
+		args.Source = &lb.ChaincodeSource{
+			Type: &lb.ChaincodeSource_Unavailable_{Unavailable: &lb.ChaincodeSource_Unavailable{}},
+		}
+	}
+
+	argBytes, err := proto.Marshal(args)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling approve chaincode definition args")
+	}
+
+	for _, orgID := range d.orgsForChannel(orgIDs, channelID) {
+		peers := d.OrgPeers(orgID, channelID)
+		if len(peers) == 0 {
+			return errors.Errorf("no peers found for org [%s] on channel [%s]", orgID, channelID)
+		}
+
+		logger.Infof("Approving chaincode definition [%s:%s], sequence [%d] for org [%s] on channel [%s]", def.Name, def.Version, def.Sequence, orgID, channelID)
+
+		_, err := d.executeLifecycle(orgID, channelID, approveFuncName, argBytes, peers[:1])
+		if err != nil {
+			return errors.WithMessagef(err, "error approving chaincode definition for org [%s]", orgID)
+		}
+	}
+
+	return nil
+}
+
+// CheckCommitReadiness returns the approval status of the given chaincode definition for each org on the channel
+func (d *CommonSteps) CheckCommitReadiness(channelID string, def *ChaincodeDefinition) (map[string]bool, error) {
+	validationParam, collections, err := d.definitionPolicies(channelID, def)
+	if err != nil {
+		return nil, err
+	}
+
+	argBytes, err := proto.Marshal(&lb.CheckCommitReadinessArgs{
+		Name:                def.Name,
+		Version:             def.Version,
+		Sequence:            def.Sequence,
+		EndorsementPlugin:   defaultEndorsementPlugin,
+		ValidationPlugin:    defaultValidationPlugin,
+		ValidationParameter: validationParam,
+		Collections:         collections,
+		InitRequired:        def.InitRequired,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling check commit readiness args")
+	}
+
+	chClient, peers, err := d.lifecycleQueryClient(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := chClient.Query(
+		channel.Request{ChaincodeID: lifecycleCC, Fcn: checkCommitReadinessFunc, Args: [][]byte{argBytes}},
+		channel.WithTargets(peers...),
+		channel.WithRetry(retry.DefaultChannelOpts),
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error checking commit readiness")
+	}
+
+	result := &lb.CheckCommitReadinessResult{}
+	if err := proto.Unmarshal(resp.Payload, result); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling check commit readiness result")
+	}
+
+	return result.Approvals, nil
+}
+
+// CommitChaincodeDefinition commits the given chaincode definition to the channel. The commit
+// proposal is endorsed by a peer from each of the given orgs (or all orgs on the channel if orgIDs is empty).
+func (d *CommonSteps) CommitChaincodeDefinition(channelID, orgIDs string, def *ChaincodeDefinition) error {
+	validationParam, collections, err := d.definitionPolicies(channelID, def)
+	if err != nil {
+		return err
+	}
+
+	argBytes, err := proto.Marshal(&lb.CommitChaincodeDefinitionArgs{
+		Name:                def.Name,
+		Version:             def.Version,
+		Sequence:            def.Sequence,
+		EndorsementPlugin:   defaultEndorsementPlugin,
+		ValidationPlugin:    defaultValidationPlugin,
+		ValidationParameter: validationParam,
+		Collections:         collections,
+		InitRequired:        def.InitRequired,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshalling commit chaincode definition args")
+	}
+
+	orgs := d.orgsForChannel(orgIDs, channelID)
+	if len(orgs) == 0 {
+		return errors.Errorf("no orgs found for channel [%s]", channelID)
+	}
+
+	var targets []*PeerConfig
+	for _, orgID := range orgs {
+		peers := d.OrgPeers(orgID, channelID)
+		if len(peers) == 0 {
+			return errors.Errorf("no peers found for org [%s] on channel [%s]", orgID, channelID)
+		}
+		targets = append(targets, peers[0])
+	}
+
+	logger.Infof("Committing chaincode definition [%s:%s], sequence [%d] on channel [%s] with endorsements from orgs %s", def.Name, def.Version, def.Sequence, channelID, orgs)
+
+	_, err = d.executeLifecycle(orgs[0], channelID, commitFuncName, argBytes, targets)
+	if err != nil {
+		return errors.WithMessage(err, "error committing chaincode definition")
+	}
+
+	return nil
+}
+
+// QueryCommittedChaincodeDefinition returns the committed chaincode definition for the given chaincode
+func (d *CommonSteps) QueryCommittedChaincodeDefinition(channelID, ccID string) (*lb.QueryChaincodeDefinitionResult, error) {
+	argBytes, err := proto.Marshal(&lb.QueryChaincodeDefinitionArgs{Name: ccID})
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling query chaincode definition args")
+	}
+
+	chClient, peers, err := d.lifecycleQueryClient(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := chClient.Query(
+		channel.Request{ChaincodeID: lifecycleCC, Fcn: queryDefinitionFuncName, Args: [][]byte{argBytes}},
+		channel.WithTargets(peers...),
+		channel.WithRetry(retry.DefaultChannelOpts),
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error querying chaincode definition for [%s]", ccID)
+	}
+
+	result := &lb.QueryChaincodeDefinitionResult{}
+	if err := proto.Unmarshal(resp.Payload, result); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling query chaincode definition result")
+	}
+
+	return result, nil
+}
+
+func (d *CommonSteps) lifecycleQueryClient(channelID string) (*channel.Client, []fabApi.Peer, error) {
+	orgID, err := d.BDDContext.OrgIDForChannel(channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orgPeers := d.OrgPeers(orgID, channelID)
+	if len(orgPeers) == 0 {
+		return nil, nil, errors.Errorf("no peers found for org [%s] on channel [%s]", orgID, channelID)
+	}
+
+	chClient, err := d.BDDContext.OrgChannelClient(orgID, ADMIN, channelID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to create new channel client")
+	}
+
+	peers, err := d.sdkPeers(orgPeers[:1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return chClient, peers, nil
+}
+
+func (d *CommonSteps) executeLifecycle(orgID, channelID, fcn string, argBytes []byte, targets []*PeerConfig) (channel.Response, error) {
+	chClient, err := d.BDDContext.OrgChannelClient(orgID, ADMIN, channelID)
+	if err != nil {
+		return channel.Response{}, errors.WithMessage(err, "Failed to create new channel client")
+	}
+
+	peers, err := d.sdkPeers(targets)
+	if err != nil {
+		return channel.Response{}, err
+	}
+
+	return chClient.Execute(
+		channel.Request{ChaincodeID: lifecycleCC, Fcn: fcn, Args: [][]byte{argBytes}},
+		channel.WithTargets(peers...),
+		channel.WithTimeout(fabApi.Execute, lifecycleTransactTimeout),
+		channel.WithRetry(retry.DefaultChannelOpts),
+	)
+}
+
+func (d *CommonSteps) definitionPolicies(channelID string, def *ChaincodeDefinition) ([]byte, *common.CollectionConfigPackage, error) {
+	chaincodePolicy, err := d.newChaincodePolicy(def.Policy, channelID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "error creating endorsement policy")
+	}
+
+	validationParam, err := proto.Marshal(&pb.ApplicationPolicy{
+		Type: &pb.ApplicationPolicy_SignaturePolicy{SignaturePolicy: chaincodePolicy},
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error marshalling endorsement policy")
+	}
+
+	if len(def.CollectionNames) == 0 {
+		return validationParam, nil, nil
+	}
+
+	collections := &common.CollectionConfigPackage{}
+	for _, collName := range def.CollectionNames {
+		logger.Infof("Configuring collection (%s) for CCID=%s", collName, def.Name)
+		c, err := d.newCollectionConfig(channelID, collName)
+		if err != nil {
+			return nil, nil, err
+		}
+		collections.Config = append(collections.Config, c)
+	}
+
+	return validationParam, collections, nil
+}
+
+func (d *CommonSteps) sdkPeers(pconfigs []*PeerConfig) ([]fabApi.Peer, error) {
+	var peers []fabApi.Peer
+	for _, pconfig := range pconfigs {
+		p, err := d.BDDContext.OrgUserContext(pconfig.OrgID, ADMIN).InfraProvider().CreatePeerFromConfig(&fabApi.NetworkPeer{PeerConfig: pconfig.Config})
+		if err != nil {
+			return nil, errors.WithMessage(err, "NewPeer failed")
+		}
+		peers = append(peers, p)
+	}
+	return peers, nil
+}
+
+func (d *CommonSteps) orgsForChannel(orgIDs, channelID string) []string {
+	if orgIDs != "" {
+		return strings.Split(orgIDs, ",")
+	}
+	return d.BDDContext.OrgsByChannel(channelID)
+}
+
+func (d *CommonSteps) packageChaincode(ccType, label, ccPath string) error {
+	logger.Infof("Packaging chaincode from path [%s] with label [%s]", ccPath, label)
+	_, err := d.PackageChaincode(ccType, label, ccPath)
+	return err
+}
+
+func (d *CommonSteps) installChaincodePackageToAllPeers(label string) error {
+	logger.Infof("Installing chaincode package [%s] to all peers", label)
+	_, err := d.InstallChaincodePackage(label, "", "")
+	return err
+}
+
+func (d *CommonSteps) installChaincodePackageToAllPeersAsUser(label, userType, orgID string) error {
+	logger.Infof("Installing chaincode package [%s] to all peers as user [%s] of org [%s]", label, userType, orgID)
+	_, err := d.InstallChaincodePackageAsUser(label, "", "", orgID, userType)
+	return err
+}
+
+func (d *CommonSteps) installChaincodePackageToOrg(label, orgIDs string) error {
+	logger.Infof("Installing chaincode package [%s] to all peers in orgs [%s]", label, orgIDs)
+	_, err := d.InstallChaincodePackage(label, orgIDs, "")
+	return err
+}
+
+func (d *CommonSteps) installChaincodePackageToOrgAsUser(label, orgIDs, userType, orgID string) error {
+	logger.Infof("Installing chaincode package [%s] to all peers in orgs [%s] as user [%s] of org [%s]", label, orgIDs, userType, orgID)
+	_, err := d.InstallChaincodePackageAsUser(label, orgIDs, "", orgID, userType)
+	return err
+}
+
+func (d *CommonSteps) approveChaincodeDefinition(ccID, ccVersion string, sequence int, label, orgIDs, channelID, ccPolicy, collectionNames string) error {
+	return d.doApproveChaincodeDefinition(ccID, ccVersion, sequence, label, orgIDs, channelID, ccPolicy, collectionNames, false)
+}
+
+func (d *CommonSteps) approveChaincodeDefinitionWithInit(ccID, ccVersion string, sequence int, label, orgIDs, channelID, ccPolicy, collectionNames string) error {
+	return d.doApproveChaincodeDefinition(ccID, ccVersion, sequence, label, orgIDs, channelID, ccPolicy, collectionNames, true)
+}
+
+func (d *CommonSteps) doApproveChaincodeDefinition(ccID, ccVersion string, sequence int, label, orgIDs, channelID, ccPolicy, collectionNames string, initRequired bool) error {
+	def := newChaincodeDefinition(ccID, ccVersion, sequence, ccPolicy, collectionNames, initRequired)
+
+	if label != "" {
+		pkg := d.BDDContext.ChaincodePackage(label)
+		if pkg == nil {
+			return errors.Errorf("chaincode package [%s] not found", label)
+		}
+		def.PackageID = pkg.PackageID()
+	}
+
+	return d.ApproveChaincodeDefinition(channelID, orgIDs, def)
+}
+
+func (d *CommonSteps) checkCommitReadiness(ccID, ccVersion string, sequence int, channelID, ccPolicy, collectionNames string) error {
+	return d.doCheckCommitReadiness(ccID, ccVersion, sequence, channelID, ccPolicy, collectionNames, false)
+}
+
+func (d *CommonSteps) checkCommitReadinessWithInit(ccID, ccVersion string, sequence int, channelID, ccPolicy, collectionNames string) error {
+	return d.doCheckCommitReadiness(ccID, ccVersion, sequence, channelID, ccPolicy, collectionNames, true)
+}
+
+func (d *CommonSteps) doCheckCommitReadiness(ccID, ccVersion string, sequence int, channelID, ccPolicy, collectionNames string, initRequired bool) error {
+	d.BDDContext.Vars().ClearResponse()
+
+	approvals, err := d.CheckCommitReadiness(channelID, newChaincodeDefinition(ccID, ccVersion, sequence, ccPolicy, collectionNames, initRequired))
+	if err != nil {
+		return err
+	}
+
+	approvalsBytes, err := json.Marshal(approvals)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling approvals")
+	}
+
+	d.BDDContext.Vars().SetResponse(string(approvalsBytes))
+	logger.Infof("Commit readiness for chaincode [%s:%s], sequence [%d]: %s", ccID, ccVersion, sequence, approvalsBytes)
+	return nil
+}
+
+func (d *CommonSteps) commitChaincodeDefinition(ccID, ccVersion string, sequence int, orgIDs, channelID, ccPolicy, collectionNames string) error {
+	return d.CommitChaincodeDefinition(channelID, orgIDs, newChaincodeDefinition(ccID, ccVersion, sequence, ccPolicy, collectionNames, false))
+}
+
+func (d *CommonSteps) commitChaincodeDefinitionWithInit(ccID, ccVersion string, sequence int, orgIDs, channelID, ccPolicy, collectionNames string) error {
+	return d.CommitChaincodeDefinition(channelID, orgIDs, newChaincodeDefinition(ccID, ccVersion, sequence, ccPolicy, collectionNames, true))
+}
+
+func newChaincodeDefinition(ccID, ccVersion string, sequence int, ccPolicy, collectionNames string, initRequired bool) *ChaincodeDefinition {
+	def := &ChaincodeDefinition{
+		Name:         ccID,
+		Version:      ccVersion,
+		Sequence:     int64(sequence),
+		Policy:       ccPolicy,
+		InitRequired: initRequired,
+	}
+	if collectionNames != "" {
+		def.CollectionNames = strings.Split(collectionNames, ",")
+	}
+	return def
+}
@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChaincodePackage(t *testing.T) {
+	goPath, err := ioutil.TempDir("", "gopath")
+	require.NoError(t, err)
+	defer os.RemoveAll(goPath)
+
+	ccDir := filepath.Join(goPath, "src", "example", "cc")
+	require.NoError(t, os.MkdirAll(ccDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(ccDir, "cc.go"), []byte("package main\n"), 0644))
+
+	t.Run("Success", func(t *testing.T) {
+		pkg, err := NewChaincodePackage("cc_v1", "example/cc", goPath)
+		require.NoError(t, err)
+		require.NotNil(t, pkg)
+
+		assert.Equal(t, "cc_v1", pkg.Label)
+		assert.True(t, strings.HasPrefix(pkg.PackageID(), "cc_v1:"))
+		assert.Len(t, strings.TrimPrefix(pkg.PackageID(), "cc_v1:"), 64)
+
+		entries := readTarGz(t, pkg.Bytes)
+		require.Contains(t, entries, lifecyclePackageMetadata)
+		require.Contains(t, entries, lifecyclePackageCode)
+
+		metadata := &packageMetadata{}
+		require.NoError(t, json.Unmarshal(entries[lifecyclePackageMetadata], metadata))
+		assert.Equal(t, "example/cc", metadata.Path)
+		assert.Equal(t, "golang", metadata.Type)
+		assert.Equal(t, "cc_v1", metadata.Label)
+
+		code := readTarGz(t, entries[lifecyclePackageCode])
+		assert.Contains(t, code, "src/example/cc/cc.go")
+	})
+
+	t.Run("No label", func(t *testing.T) {
+		_, err := NewChaincodePackage("", "example/cc", goPath)
+		assert.EqualError(t, err, "chaincode package label must be provided")
+	})
+
+	t.Run("No path", func(t *testing.T) {
+		_, err := NewChaincodePackage("cc_v1", "", goPath)
+		assert.Error(t, err)
+	})
+}
+
+func readTarGz(t *testing.T, b []byte) map[string][]byte {
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		contents, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		entries[hdr.Name] = contents
+	}
+	return entries
+}
@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetworkFault holds the traffic-control settings applied to a container's network interfaces.
+// A zero value for a setting means that the setting is not applied.
+type NetworkFault struct {
+	Delay  time.Duration
+	Jitter time.Duration
+	// Loss is the percentage of packets to drop
+	Loss float64
+	// Rate is the bandwidth limit in tc rate units (for example, "1mbit" or "500kbit")
+	Rate string
+}
+
+// IsEmpty returns true if no settings are applied
+func (f NetworkFault) IsEmpty() bool {
+	return f.Delay == 0 && f.Jitter == 0 && f.Loss == 0 && f.Rate == ""
+}
+
+// netemArgs returns the netem qdisc arguments for the fault
+func (f NetworkFault) netemArgs() []string {
+	var args []string
+	if f.Delay > 0 || f.Jitter > 0 {
+		args = append(args, "delay", formatMillis(f.Delay))
+		if f.Jitter > 0 {
+			args = append(args, formatMillis(f.Jitter))
+		}
+	}
+	if f.Loss > 0 {
+		args = append(args, "loss", strconv.FormatFloat(f.Loss, 'f', -1, 64)+"%")
+	}
+	if f.Rate != "" {
+		args = append(args, "rate", f.Rate)
+	}
+	return args
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+}
+
+// ApplyNetworkFault merges the given settings (the non-zero fields) with any settings already applied to the
+// container for the given compose service and applies the result to all of the container's network interfaces
+// using tc/netem. The container image must include the tc command and the container requires the NET_ADMIN capability.
+func (c *Composition) ApplyNetworkFault(composeService string, fault NetworkFault) error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	merged := c.networkFaults[containerID]
+	if fault.Delay > 0 {
+		merged.Delay = fault.Delay
+	}
+	if fault.Jitter > 0 {
+		merged.Jitter = fault.Jitter
+	}
+	if fault.Loss > 0 {
+		merged.Loss = fault.Loss
+	}
+	if fault.Rate != "" {
+		merged.Rate = fault.Rate
+	}
+
+	interfaces, err := c.networkInterfaces(containerID)
+	if err != nil {
+		return err
+	}
+
+	for _, iface := range interfaces {
+		cmd := append([]string{"tc", "qdisc", "replace", "dev", iface, "root", "netem"}, merged.netemArgs()...)
+		logger.Infof("Applying network fault to compose service '%s': %s", composeService, strings.Join(cmd, " "))
+		if _, err := c.dockerHelper.Exec(containerID, cmd...); err != nil {
+			return fmt.Errorf("Error applying network fault to compose service '%s' (the container requires the tc command and the NET_ADMIN capability):  %s", composeService, err)
+		}
+	}
+
+	c.networkFaults[containerID] = merged
+	return nil
+}
+
+// RemoveNetworkFault removes all traffic-control settings from the container for the given compose service
+func (c *Composition) RemoveNetworkFault(composeService string) error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.networkFaults[containerID]; !ok {
+		return fmt.Errorf("No network fault applied to compose service '%s'", composeService)
+	}
+
+	return c.removeNetworkFault(containerID)
+}
+
+// RemoveAllNetworkFaults removes the traffic-control settings from all containers to which they were applied
+func (c *Composition) RemoveAllNetworkFaults() error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	var errs []string
+	for containerID := range c.networkFaults {
+		if err := c.removeNetworkFault(containerID); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Error removing network faults:  %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *Composition) removeNetworkFault(containerID string) error {
+	// The fault is forgotten even if removal fails since the container may no longer be running
+	delete(c.networkFaults, containerID)
+
+	interfaces, err := c.networkInterfaces(containerID)
+	if err != nil {
+		return err
+	}
+
+	for _, iface := range interfaces {
+		logger.Infof("Removing network fault from container '%s' on interface %s", containerID, iface)
+		if _, err := c.dockerHelper.Exec(containerID, "tc", "qdisc", "del", "dev", iface, "root"); err != nil {
+			return fmt.Errorf("Error removing network fault from container '%s':  %s", containerID, err)
+		}
+	}
+	return nil
+}
+
+// networkInterfaces returns the names of the container's network interfaces excluding the loopback interface
+func (c *Composition) networkInterfaces(containerID string) ([]string, error) {
+	output, err := c.dockerHelper.Exec(containerID, "ls", "/sys/class/net")
+	if err != nil {
+		return nil, fmt.Errorf("Error listing network interfaces in container '%s':  %s", containerID, err)
+	}
+
+	var interfaces []string
+	for _, iface := range strings.Fields(output) {
+		if iface != "lo" {
+			interfaces = append(interfaces, iface)
+		}
+	}
+	if len(interfaces) == 0 {
+		return nil, fmt.Errorf("No network interfaces found in container '%s'", containerID)
+	}
+	return interfaces, nil
+}
@@ -0,0 +1,29 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkFaultNetemArgs(t *testing.T) {
+	assert.True(t, NetworkFault{}.IsEmpty())
+	assert.Empty(t, NetworkFault{}.netemArgs())
+
+	f := NetworkFault{Delay: 100 * time.Millisecond}
+	assert.False(t, f.IsEmpty())
+	assert.Equal(t, []string{"delay", "100ms"}, f.netemArgs())
+
+	f = NetworkFault{Delay: 200 * time.Millisecond, Jitter: 50 * time.Millisecond, Loss: 2.5, Rate: "1mbit"}
+	assert.Equal(t, []string{"delay", "200ms", "50ms", "loss", "2.5%", "rate", "1mbit"}, f.netemArgs())
+
+	f = NetworkFault{Loss: 10}
+	assert.Equal(t, []string{"loss", "10%"}, f.netemArgs())
+}
@@ -0,0 +1,298 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// networkAttachment holds the details required in order to reconnect a container to a network
+type networkAttachment struct {
+	networkID   string
+	networkName string
+	aliases     []string
+}
+
+// DisconnectContainer disconnects the container for the given compose service from all of its networks.
+// The container may be reconnected with ReconnectContainer.
+func (c *Composition) DisconnectContainer(composeService string) error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.disconnectedContainers[containerID]; ok {
+		return fmt.Errorf("Container for compose service '%s' is already disconnected", composeService)
+	}
+
+	return c.disconnect(containerID, composeService)
+}
+
+// ReconnectContainer reconnects the container for the given compose service to the networks
+// from which it was disconnected
+func (c *Composition) ReconnectContainer(composeService string) error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.disconnectedContainers[containerID]; !ok {
+		return fmt.Errorf("Container for compose service '%s' is not disconnected", composeService)
+	}
+
+	return c.reconnect(containerID)
+}
+
+// PartitionContainers partitions the network such that the containers in group1 can no longer communicate
+// with the containers in group2. Containers within the same group may still communicate with each other
+// and all other containers on the network remain reachable from both groups.
+//
+// The partition is implemented by moving each group onto its own network and attaching all of the other
+// containers on the original networks to both of the new networks.
+func (c *Composition) PartitionContainers(group1, group2 []string) error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	groups := [][]string{group1, group2}
+	groupContainerIDs := make([][]string, len(groups))
+	partitioned := make(map[string]bool)
+
+	for i, group := range groups {
+		if len(group) == 0 {
+			return fmt.Errorf("Network partition requires at least one container in each group")
+		}
+		for _, composeService := range group {
+			containerID, err := c.containerIDForService(composeService)
+			if err != nil {
+				return err
+			}
+			if partitioned[containerID] {
+				return fmt.Errorf("Container for compose service '%s' may only be in one partition group", composeService)
+			}
+			if _, ok := c.disconnectedContainers[containerID]; ok {
+				return fmt.Errorf("Container for compose service '%s' is already disconnected", composeService)
+			}
+			partitioned[containerID] = true
+			groupContainerIDs[i] = append(groupContainerIDs[i], containerID)
+		}
+	}
+
+	// The remaining containers are those attached to the same networks as the partitioned containers
+	others, err := c.connectedContainers(partitioned)
+	if err != nil {
+		return err
+	}
+
+	for i, containerIDs := range groupContainerIDs {
+		networkName := fmt.Sprintf("%s_partition%d_%d", c.projectName, len(c.partitionNetworks), i+1)
+		network, err := c.dockerClient.CreateNetwork(docker.CreateNetworkOptions{Name: networkName, Driver: "bridge", CheckDuplicate: true})
+		if err != nil {
+			return fmt.Errorf("Error creating partition network '%s':  %s", networkName, err)
+		}
+		c.partitionNetworks = append(c.partitionNetworks, network.ID)
+
+		for _, containerID := range containerIDs {
+			attachments, err := c.networkAttachments(containerID)
+			if err != nil {
+				return err
+			}
+			if err := c.disconnect(containerID, containerID); err != nil {
+				return err
+			}
+			if err := c.connect(containerID, network.ID, networkName, allAliases(attachments)); err != nil {
+				return err
+			}
+		}
+
+		for containerID, aliases := range others {
+			if err := c.connect(containerID, network.ID, networkName, aliases); err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.Infof("Partitioned containers %s from containers %s", group1, group2)
+	return nil
+}
+
+// HealNetworkPartitions removes all partition networks and reconnects all disconnected containers
+// to their original networks. Any networks or containers which could not be healed are retained so
+// that a subsequent call may retry.
+func (c *Composition) HealNetworkPartitions() error {
+	c.faultMutex.Lock()
+	defer c.faultMutex.Unlock()
+
+	var errs []string
+	var failedNetworks []string
+	for _, networkID := range c.partitionNetworks {
+		if err := c.removeNetwork(networkID); err != nil {
+			errs = append(errs, err.Error())
+			failedNetworks = append(failedNetworks, networkID)
+		}
+	}
+	c.partitionNetworks = failedNetworks
+
+	for containerID := range c.disconnectedContainers {
+		if err := c.reconnect(containerID); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Error healing network partitions:  %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *Composition) containerIDForService(composeService string) (string, error) {
+	apiContainer, err := c.GetAPIContainerForComposeService(composeService)
+	if err != nil {
+		return "", err
+	}
+	return apiContainer.ID, nil
+}
+
+func (c *Composition) networkAttachments(containerID string) ([]networkAttachment, error) {
+	container, err := c.dockerClient.InspectContainer(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("Error inspecting container '%s':  %s", containerID, err)
+	}
+	if container.NetworkSettings == nil {
+		return nil, nil
+	}
+
+	var attachments []networkAttachment
+	for name, network := range container.NetworkSettings.Networks {
+		attachments = append(attachments, networkAttachment{networkID: network.NetworkID, networkName: name, aliases: network.Aliases})
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].networkName < attachments[j].networkName })
+	return attachments, nil
+}
+
+// connectedContainers returns the aliases of all containers (other than the excluded ones) which share
+// a network with any of the excluded containers
+func (c *Composition) connectedContainers(exclude map[string]bool) (map[string][]string, error) {
+	networkIDs := make(map[string]bool)
+	for containerID := range exclude {
+		attachments, err := c.networkAttachments(containerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range attachments {
+			networkIDs[a.networkID] = true
+		}
+	}
+
+	others := make(map[string][]string)
+	for networkID := range networkIDs {
+		network, err := c.dockerClient.NetworkInfo(networkID)
+		if err != nil {
+			return nil, fmt.Errorf("Error inspecting network '%s':  %s", networkID, err)
+		}
+		for containerID := range network.Containers {
+			if exclude[containerID] {
+				continue
+			}
+			if _, ok := others[containerID]; ok {
+				continue
+			}
+			attachments, err := c.networkAttachments(containerID)
+			if err != nil {
+				return nil, err
+			}
+			others[containerID] = allAliases(attachments)
+		}
+	}
+	return others, nil
+}
+
+func (c *Composition) disconnect(containerID, name string) error {
+	attachments, err := c.networkAttachments(containerID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return fmt.Errorf("Container '%s' is not connected to any network", name)
+	}
+
+	for _, a := range attachments {
+		logger.Infof("Disconnecting container '%s' from network '%s'", name, a.networkName)
+		if err := c.dockerClient.DisconnectNetwork(a.networkID, docker.NetworkConnectionOptions{Container: containerID, Force: true}); err != nil {
+			return fmt.Errorf("Error disconnecting container '%s' from network '%s':  %s", name, a.networkName, err)
+		}
+	}
+
+	c.disconnectedContainers[containerID] = attachments
+	return nil
+}
+
+// reconnect connects the given container to the networks from which it was disconnected. If a connection
+// fails then the networks which have not yet been reconnected are retained so that a subsequent call may retry.
+func (c *Composition) reconnect(containerID string) error {
+	attachments := c.disconnectedContainers[containerID]
+	for i, a := range attachments {
+		if err := c.connect(containerID, a.networkID, a.networkName, a.aliases); err != nil {
+			c.disconnectedContainers[containerID] = attachments[i:]
+			return err
+		}
+	}
+	delete(c.disconnectedContainers, containerID)
+	return nil
+}
+
+func (c *Composition) connect(containerID, networkID, networkName string, aliases []string) error {
+	logger.Infof("Connecting container '%s' to network '%s' with aliases %s", containerID, networkName, aliases)
+	err := c.dockerClient.ConnectNetwork(networkID, docker.NetworkConnectionOptions{
+		Container:      containerID,
+		EndpointConfig: &docker.EndpointConfig{Aliases: aliases},
+	})
+	if err != nil {
+		return fmt.Errorf("Error connecting container '%s' to network '%s':  %s", containerID, networkName, err)
+	}
+	return nil
+}
+
+func (c *Composition) removeNetwork(networkID string) error {
+	network, err := c.dockerClient.NetworkInfo(networkID)
+	if err != nil {
+		return fmt.Errorf("Error inspecting network '%s':  %s", networkID, err)
+	}
+	for containerID := range network.Containers {
+		if err := c.dockerClient.DisconnectNetwork(networkID, docker.NetworkConnectionOptions{Container: containerID, Force: true}); err != nil {
+			return fmt.Errorf("Error disconnecting container '%s' from network '%s':  %s", containerID, network.Name, err)
+		}
+	}
+	logger.Infof("Removing partition network '%s'", network.Name)
+	if err := c.dockerClient.RemoveNetwork(networkID); err != nil {
+		return fmt.Errorf("Error removing network '%s':  %s", network.Name, err)
+	}
+	return nil
+}
+
+func allAliases(attachments []networkAttachment) []string {
+	var aliases []string
+	exists := make(map[string]bool)
+	for _, a := range attachments {
+		for _, alias := range a.aliases {
+			if !exists[alias] {
+				exists[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	return aliases
+}
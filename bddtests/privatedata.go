@@ -0,0 +1,281 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+)
+
+// privateDataPurgeTimeout is the time to wait for all peers to commit the blocks which purge the private data
+const privateDataPurgeTimeout = 30 * time.Second
+
+// privateDataResult contains the result of a private data query on a single peer
+type privateDataResult struct {
+	peer  *PeerConfig
+	value string
+	err   error
+}
+
+// present returns true if the peer returned a (non-empty) value for the private data query
+func (r *privateDataResult) present() bool {
+	return r.err == nil && r.value != ""
+}
+
+// queryPrivateData resolves the given args and queries the chaincode on each of the given peers individually. The
+// chaincode is expected to return the private data (or its hash) for the given args. An error returned by a peer (for
+// example, when the peer's org is not a member of the collection) is recorded in the result rather than returned.
+func (d *CommonSteps) queryPrivateData(ccID, args, channelID string, peers Peers) ([]*privateDataResult, error) {
+	argArr, err := d.BDDContext.Vars().ResolveAllVars(args)
+	if err != nil {
+		return nil, err
+	}
+	return d.queryPrivateDataWithArgArray(ccID, argArr, channelID, peers)
+}
+
+func (d *CommonSteps) queryPrivateDataWithArgArray(ccID string, argArr []string, channelID string, peers Peers) ([]*privateDataResult, error) {
+	if len(peers) == 0 {
+		return nil, errors.Errorf("no peers found for channel [%s]", channelID)
+	}
+
+	var results []*privateDataResult
+	for _, peer := range peers {
+		value, err := d.QueryCCWithArgs(false, ccID, channelID, argArr, nil, peer)
+		if err != nil {
+			logger.Infof("Private data query with args %s on peer [%s] returned error: %s", argArr, peer.PeerID, err)
+		} else {
+			logger.Infof("Private data query with args %s on peer [%s] returned value [%s]", argArr, peer.PeerID, value)
+		}
+		results = append(results, &privateDataResult{peer: peer, value: value, err: err})
+	}
+
+	return results, nil
+}
+
+func (d *CommonSteps) privateDataIsPresentOnOrg(ccID, args, orgIDs, channelID string) error {
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers(orgIDs, channelID))
+	if err != nil {
+		return err
+	}
+	return checkPrivateData(results, func(*PeerConfig) bool { return true })
+}
+
+func (d *CommonSteps) privateDataIsAbsentOnOrg(ccID, args, orgIDs, channelID string) error {
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers(orgIDs, channelID))
+	if err != nil {
+		return err
+	}
+	return checkPrivateData(results, func(*PeerConfig) bool { return false })
+}
+
+func (d *CommonSteps) privateDataIsPresentOnlyOnMembers(ccID, args, collConfigID, channelID string) error {
+	members, err := d.collectionMembers(channelID, collConfigID)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	return checkPrivateData(results, func(peer *PeerConfig) bool { return members[peer.MspID] })
+}
+
+func (d *CommonSteps) privateDataHashIsPresentOnAllPeers(ccID, args, channelID string) error {
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+	return checkPrivateDataHash(results, nil)
+}
+
+func (d *CommonSteps) privateDataHashOfValueIsPresentOnAllPeers(ccID, args, value, channelID string) error {
+	value, err := d.BDDContext.Vars().Resolve(value)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(value))
+	return checkPrivateDataHash(results, hash[:])
+}
+
+// privateDataIsPurged generates blocks (by invoking the given chaincode function, which must write to the ledger)
+// until the channel height has increased by blocksToLive+1 since the step started. Once all peers of
+// the channel have reached that height, the private data is checked to have been purged from all peers.
+// The private data must have been committed in a block prior to invoking this step.
+func (d *CommonSteps) privateDataIsPurged(ccID, args, collConfigID, invokeCCID, invokeArgs, channelID string) error {
+	collConfig, err := d.newCollectionConfig(channelID, collConfigID)
+	if err != nil {
+		return err
+	}
+
+	blocksToLive := collConfig.GetStaticCollectionConfig().GetBlockToLive()
+	if blocksToLive == 0 {
+		return errors.Errorf("collection config [%s] has no blocksToLive so its private data is never purged", collConfigID)
+	}
+
+	invokeArgArr, err := d.BDDContext.Vars().ResolveAllVars(invokeArgs)
+	if err != nil {
+		return err
+	}
+
+	startHeight, err := d.maxChannelHeight(channelID)
+	if err != nil {
+		return err
+	}
+
+	targetHeight := startHeight + blocksToLive + 1
+
+	logger.Infof("Generating blocks on channel [%s] up to height %d in order to purge private data in collection [%s]", channelID, targetHeight, collConfigID)
+
+	for i := uint64(1); ; i++ {
+		height, err := d.getChannelBlockHeight(channelID)
+		if err != nil {
+			return errors.WithMessage(err, "error getting channel height")
+		}
+		if uint64(height) >= targetHeight {
+			break
+		}
+
+		if _, err := d.InvokeCCWithArgs(invokeCCID, channelID, nil, invokeArgArr, nil); err != nil {
+			return errors.WithMessagef(err, "error invoking chaincode [%s] to generate blocks (invocation %d)", invokeCCID, i)
+		}
+	}
+
+	l, err := d.blockListener(channelID)
+	if err != nil {
+		return err
+	}
+
+	if err := l.WaitForHeight(targetHeight, privateDataPurgeTimeout); err != nil {
+		return err
+	}
+
+	results, err := d.queryPrivateData(ccID, args, channelID, d.OrgPeers("", channelID))
+	if err != nil {
+		return err
+	}
+
+	return checkPrivateData(results, func(*PeerConfig) bool { return false })
+}
+
+// maxChannelHeight returns the highest block height of all peers on the given channel
+func (d *CommonSteps) maxChannelHeight(channelID string) (uint64, error) {
+	infos, err := d.queryChannelInfoFromAllPeers(channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	var maxHeight uint64
+	for peerID, info := range infos {
+		height, err := strconv.ParseUint(info.Height, 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid height [%s] for peer [%s]", info.Height, peerID)
+		}
+		if height > maxHeight {
+			maxHeight = height
+		}
+	}
+
+	return maxHeight, nil
+}
+
+// collectionMembers returns the MSP IDs of the members of the collection with the given config ID
+func (d *CommonSteps) collectionMembers(channelID, collConfigID string) (map[string]bool, error) {
+	collConfig, err := d.newCollectionConfig(channelID, collConfigID)
+	if err != nil {
+		return nil, err
+	}
+	return collectionMemberMSPIDs(collConfig)
+}
+
+// checkPrivateData checks that the private data is present on each peer for which isMember returns true
+// and absent on all other peers
+func checkPrivateData(results []*privateDataResult, isMember func(peer *PeerConfig) bool) error {
+	var errs []string
+	for _, r := range results {
+		if isMember(r.peer) {
+			if !r.present() {
+				errs = append(errs, errors.Errorf("expecting private data on peer [%s] but got none (error: %v)", r.peer.PeerID, r.err).Error())
+			}
+		} else if r.present() {
+			errs = append(errs, errors.Errorf("expecting no private data on peer [%s] but got [%s]", r.peer.PeerID, r.value).Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkPrivateDataHash checks that all peers returned the same (non-empty) private data hash. If an expected hash is
+// provided then the returned hash must be equal to it, either as raw bytes or hex-encoded.
+func checkPrivateDataHash(results []*privateDataResult, expectedHash []byte) error {
+	if err := checkPrivateData(results, func(*PeerConfig) bool { return true }); err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, r := range results {
+		if r.value != results[0].value {
+			errs = append(errs, errors.Errorf("peer [%s] returned hash [%x] but peer [%s] returned hash [%x]", r.peer.PeerID, r.value, results[0].peer.PeerID, results[0].value).Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	if expectedHash != nil && results[0].value != string(expectedHash) && !strings.EqualFold(results[0].value, hex.EncodeToString(expectedHash)) {
+		return errors.Errorf("peers returned hash [%x] but expecting [%x]", results[0].value, expectedHash)
+	}
+
+	return nil
+}
+
+// collectionMemberMSPIDs returns the MSP IDs of the orgs in the member orgs policy of the given collection config
+func collectionMemberMSPIDs(collConfig *common.CollectionConfig) (map[string]bool, error) {
+	staticConfig := collConfig.GetStaticCollectionConfig()
+	if staticConfig == nil {
+		return nil, errors.New("collection config is not a static collection config")
+	}
+
+	policy := staticConfig.GetMemberOrgsPolicy().GetSignaturePolicy()
+	if policy == nil {
+		return nil, errors.Errorf("collection [%s] does not have a signature policy", staticConfig.Name)
+	}
+
+	members := make(map[string]bool)
+	for _, principal := range policy.Identities {
+		if principal.PrincipalClassification != msp.MSPPrincipal_ROLE {
+			continue
+		}
+
+		role := &msp.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return nil, errors.Wrapf(err, "error unmarshalling principal of collection [%s]", staticConfig.Name)
+		}
+		members[role.MspIdentifier] = true
+	}
+
+	return members, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	fabApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionMemberMSPIDs(t *testing.T) {
+	policy, err := newPolicy("OR('Org1MSP.member','Org2MSP.member')")
+	require.NoError(t, err)
+
+	members, err := collectionMemberMSPIDs(newPrivateCollectionConfig("coll1", 1, 2, 3, policy))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"Org1MSP": true, "Org2MSP": true}, members)
+
+	_, err = collectionMemberMSPIDs(newPrivateCollectionConfig("coll1", 1, 2, 3, nil))
+	assert.Error(t, err)
+}
+
+func TestCheckPrivateData(t *testing.T) {
+	peer1 := &PeerConfig{PeerID: "peer0.org1.example.com", MspID: "Org1MSP"}
+	peer2 := &PeerConfig{PeerID: "peer0.org2.example.com", MspID: "Org2MSP"}
+
+	results := []*privateDataResult{
+		{peer: peer1, value: "value1"},
+		{peer: peer2, err: errors.New("access denied")},
+	}
+
+	isOrg1 := func(peer *PeerConfig) bool { return peer.MspID == "Org1MSP" }
+	assert.NoError(t, checkPrivateData(results, isOrg1))
+
+	err := checkPrivateData(results, func(*PeerConfig) bool { return true })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "peer0.org2.example.com")
+
+	err = checkPrivateData(results, func(*PeerConfig) bool { return false })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "peer0.org1.example.com")
+}
+
+func TestCheckPrivateDataHash(t *testing.T) {
+	peer1 := &PeerConfig{PeerID: "peer0.org1.example.com", MspID: "Org1MSP"}
+	peer2 := &PeerConfig{PeerID: "peer0.org2.example.com", MspID: "Org2MSP"}
+
+	hash := sha256.Sum256([]byte("value1"))
+	otherHash := sha256.Sum256([]byte("value2"))
+
+	same := []*privateDataResult{{peer: peer1, value: string(hash[:])}, {peer: peer2, value: string(hash[:])}}
+	assert.NoError(t, checkPrivateDataHash(same, nil))
+	assert.NoError(t, checkPrivateDataHash(same, hash[:]))
+	assert.Error(t, checkPrivateDataHash(same, otherHash[:]))
+
+	hexEncoded := []*privateDataResult{{peer: peer1, value: hex.EncodeToString(hash[:])}, {peer: peer2, value: hex.EncodeToString(hash[:])}}
+	assert.NoError(t, checkPrivateDataHash(hexEncoded, hash[:]))
+
+	different := []*privateDataResult{{peer: peer1, value: string(hash[:])}, {peer: peer2, value: string(otherHash[:])}}
+	err := checkPrivateDataHash(different, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "peer0.org2.example.com")
+
+	missing := []*privateDataResult{{peer: peer1, value: string(hash[:])}, {peer: peer2, err: errors.New("not found")}}
+	assert.Error(t, checkPrivateDataHash(missing, nil))
+}
+
+func TestImplicitCollectionName(t *testing.T) {
+	assert.Equal(t, "_implicit_org_Org1MSP", ImplicitCollectionName("Org1MSP"))
+}
+
+func TestResolveArgsWithCollection(t *testing.T) {
+	coll := ImplicitCollectionName("Org1MSP")
+
+	args, err := resolveArgsWithCollection(map[string]string{"key": "key1", "collection": "coll1"}, coll, "putprivate,${implicit_collection},${key},${collection}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"putprivate", coll, "key1", "coll1"}, args)
+
+	_, err = resolveArgsWithCollection(map[string]string{collectionVar: "coll1"}, coll, "putprivate,${implicit_collection},key1")
+	assert.Error(t, err)
+}
+
+func TestInvokeCCWithTransientMapOnImplicitCollection(t *testing.T) {
+	b := newTestBDDContext()
+	b.orgs = []string{"org1", "org2"}
+	b.clientConfig = &testEndpointConfig{
+		networkConfig: &fabApi.NetworkConfig{
+			Organizations: map[string]fabApi.OrganizationConfig{
+				"org1": {MSPID: "Org1MSP"},
+				"org2": {MSPID: "Org2MSP"},
+			},
+		},
+	}
+	d := &CommonSteps{BDDContext: b}
+
+	peer := newRecordingPeer("peer0.org2.example.com")
+	b.orgChannelClients[orgUserChannel{orgUser: orgUser{org: "org2", user: "writer"}, channelID: "mychannel"}] = newTestChannelClient(t, "mychannel", peer)
+
+	transientData := map[string][]byte{"value": []byte("secret")}
+	b.DefineTransientMap("tm1", transientData)
+
+	err := d.invokeCCWithTransientMapOnImplicitCollectionAsUser("mycc", "putprivate,${implicit_collection},k1", "tm1", "org2", "mychannel", "writer", "org2")
+	require.Error(t, err)
+
+	require.NotNil(t, peer.invocation, "expecting the proposal to be sent to the peer")
+	assert.Equal(t, [][]byte{[]byte("putprivate"), []byte("_implicit_org_Org2MSP"), []byte("k1")}, peer.invocation.ChaincodeSpec.Input.Args)
+	assert.Equal(t, transientData, peer.transientMap)
+
+	err = d.invokeCCWithTransientMapOnImplicitCollection("mycc", "putprivate,${implicit_collection},k1", "tm2", "org2", "mychannel")
+	assert.EqualError(t, err, "transient map [tm2] is not defined")
+}
+
+// testEndpointConfig is an endpoint config which only provides the network config
+type testEndpointConfig struct {
+	fabApi.EndpointConfig
+	networkConfig *fabApi.NetworkConfig
+}
+
+func (c *testEndpointConfig) NetworkConfig() *fabApi.NetworkConfig {
+	return c.networkConfig
+}
@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+// resolveFunc is a function which may be invoked within a variable expression, e.g. ${sha256(var1)}.
+// The args are the raw (unresolved) arguments to the function.
+type resolveFunc func(vars map[string]string, args []string) (string, error)
+
+// resolveFuncs contains the functions that may be used within a variable expression:
+//
+//	uuid()            - a random (version 4) UUID
+//	random(n)         - a random string of n lower-case letters and digits (see randomString)
+//	now()             - the current time in RFC3339 format
+//	base64(var)       - the base64 encoding of the value
+//	sha256(var)       - the hex-encoded SHA-256 hash of the value
+//	env(NAME)         - the value of the given environment variable
+//	json(var, "path") - the value at the given path (gjson syntax) of the JSON value
+//	len(arr)          - the number of items in the comma-separated value
+//
+// A function argument which is enclosed in double quotes is a literal value, otherwise the argument
+// is the name of a variable whose value is used.
+var resolveFuncs = map[string]resolveFunc{
+	"uuid":   uuidFunc,
+	"random": randomFunc,
+	"now":    nowFunc,
+	"base64": base64Func,
+	"sha256": sha256Func,
+	"env":    envFunc,
+	"json":   jsonFunc,
+	"len":    lenFunc,
+}
+
+// isFuncExpr returns true if the given expression is a function invocation, e.g. sha256(var1)
+func isFuncExpr(expr string) bool {
+	open := strings.Index(expr, "(")
+	return open > 0 && strings.HasSuffix(expr, ")")
+}
+
+// resolveFuncExpr invokes the function in the given expression. The arg is the full argument
+// containing the expression and is used in error messages.
+func resolveFuncExpr(vars map[string]string, expr, arg string) (string, error) {
+	open := strings.Index(expr, "(")
+	name := strings.TrimSpace(expr[0:open])
+
+	fn, ok := resolveFuncs[name]
+	if !ok {
+		return "", errors.Errorf("unknown function [%s] for arg '%s'", name, arg)
+	}
+
+	args, err := splitFuncArgs(expr[open+1 : len(expr)-1])
+	if err != nil {
+		return "", errors.Errorf("invalid arguments for function [%s] for arg '%s': %s", name, arg, err)
+	}
+
+	value, err := fn(vars, args)
+	if err != nil {
+		return "", errors.Errorf("error invoking function [%s] for arg '%s': %s", name, arg, err)
+	}
+	return value, nil
+}
+
+// splitFuncArgs splits the given comma-separated function arguments. Commas within double quotes are not treated
+// as separators and the quotes are retained so that literal arguments may be distinguished from variable names.
+func splitFuncArgs(argsStr string) ([]string, error) {
+	if strings.TrimSpace(argsStr) == "" {
+		return nil, nil
+	}
+
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	for _, c := range argsStr {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(c)
+		case c == ',' && !inQuotes:
+			args = append(args, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if inQuotes {
+		return nil, errors.Errorf("unterminated quote in [%s]", argsStr)
+	}
+	return append(args, strings.TrimSpace(current.String())), nil
+}
+
+// literalOrName returns the given argument with any enclosing quotes removed
+func literalOrName(arg string) string {
+	if len(arg) >= 2 && strings.HasPrefix(arg, `"`) && strings.HasSuffix(arg, `"`) {
+		return arg[1 : len(arg)-1]
+	}
+	return arg
+}
+
+// argValue returns the literal value of a quoted argument or the value of the variable with the given name
+func argValue(vars map[string]string, arg string) string {
+	if len(arg) >= 2 && strings.HasPrefix(arg, `"`) && strings.HasSuffix(arg, `"`) {
+		return arg[1 : len(arg)-1]
+	}
+	return vars[arg]
+}
+
+func checkNumArgs(args []string, expected int) error {
+	if len(args) != expected {
+		return errors.Errorf("expecting %d argument(s) but got %d", expected, len(args))
+	}
+	return nil
+}
+
+func uuidFunc(_ map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 0); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// Set the version (4) and variant (RFC 4122) bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func randomFunc(_ map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 1); err != nil {
+		return "", err
+	}
+
+	n, err := strconv.ParseInt(literalOrName(args[0]), 10, 64)
+	if err != nil || n <= 0 {
+		return "", errors.Errorf("expecting a positive integer but got [%s]", args[0])
+	}
+
+	return randomString(int(n)), nil
+}
+
+func nowFunc(_ map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 0); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format(time.RFC3339), nil
+}
+
+func base64Func(vars map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 1); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(argValue(vars, args[0]))), nil
+}
+
+func sha256Func(vars map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 1); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(argValue(vars, args[0])))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func envFunc(_ map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 1); err != nil {
+		return "", err
+	}
+	return os.Getenv(literalOrName(args[0])), nil
+}
+
+func jsonFunc(vars map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 2); err != nil {
+		return "", err
+	}
+
+	doc := argValue(vars, args[0])
+	if !gjson.Valid(doc) {
+		return "", errors.Errorf("value of [%s] is not valid JSON", args[0])
+	}
+	return gjson.Get(doc, argValue(vars, args[1])).String(), nil
+}
+
+func lenFunc(vars map[string]string, args []string) (string, error) {
+	if err := checkNumArgs(args, 1); err != nil {
+		return "", err
+	}
+
+	value := argValue(vars, args[0])
+	if value == "" {
+		return "0", nil
+	}
+	return strconv.Itoa(len(strings.Split(value, ","))), nil
+}
@@ -0,0 +1,328 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DATA-DOG/godog/gherkin"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+// JSON types which may be asserted with the "is of type" step
+const (
+	jsonTypeString  = "string"
+	jsonTypeNumber  = "number"
+	jsonTypeBoolean = "boolean"
+	jsonTypeNull    = "null"
+	jsonTypeObject  = "object"
+	jsonTypeArray   = "array"
+)
+
+func (d *CommonSteps) responseMatches(expr string) error {
+	expr, err := d.BDDContext.Vars().Resolve(expr)
+	if err != nil {
+		return err
+	}
+	return checkMatches("response", d.BDDContext.Vars().Response(), expr)
+}
+
+func (d *CommonSteps) responseEqualsJSON(expected string) error {
+	expected, err := d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+	return checkJSONEquals(d.BDDContext.Vars().Response(), expected)
+}
+
+func (d *CommonSteps) responseEqualsJSONDocString(doc *gherkin.DocString) error {
+	return d.responseEqualsJSON(doc.Content)
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseMatches(path, expr string) error {
+	r, err := d.jsonPathOfCCResponse(path)
+	if err != nil {
+		return err
+	}
+
+	expr, err = d.BDDContext.Vars().Resolve(expr)
+	if err != nil {
+		return err
+	}
+
+	return checkMatches("JSON path", r.String(), expr)
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseEqualsJSON(path, expected string) error {
+	r, err := d.jsonPathOfCCResponse(path)
+	if err != nil {
+		return err
+	}
+
+	expected, err = d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+
+	return checkJSONEquals(r.Raw, expected)
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseIsGreaterThan(path, expected string) error {
+	return d.compareJSONPathOfCCResponse(path, func(value float64, bounds []float64) bool {
+		return value > bounds[0]
+	}, "greater than", expected)
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseIsLessThan(path, expected string) error {
+	return d.compareJSONPathOfCCResponse(path, func(value float64, bounds []float64) bool {
+		return value < bounds[0]
+	}, "less than", expected)
+}
+
+// jsonPathOfCCResponseIsBetween checks that the value at the given JSON path is within the given (inclusive) bounds
+func (d *CommonSteps) jsonPathOfCCResponseIsBetween(path, lower, upper string) error {
+	return d.compareJSONPathOfCCResponse(path, func(value float64, bounds []float64) bool {
+		return value >= bounds[0] && value <= bounds[1]
+	}, "between", lower, upper)
+}
+
+// jsonPathOfCCResponseIsBoolean checks that the value at the given JSON path is the expected boolean. The expected
+// value may be a variable expression, e.g. ${active}, which must resolve to true or false.
+func (d *CommonSteps) jsonPathOfCCResponseIsBoolean(path, expected string) error {
+	r, err := d.jsonPathOfCCResponse(path)
+	if err != nil {
+		return err
+	}
+
+	expected, err = d.BDDContext.Vars().Resolve(expected)
+	if err != nil {
+		return err
+	}
+
+	expectedValue, err := strconv.ParseBool(expected)
+	if err != nil {
+		return errors.Errorf("expected value [%s] is not a boolean", expected)
+	}
+
+	if r.Type != gjson.True && r.Type != gjson.False {
+		return errors.Errorf("JSON path [%s] resolves to [%s] which is not a boolean", path, r.Raw)
+	}
+
+	if r.Bool() != expectedValue {
+		return errors.Errorf("JSON path [%s] resolves to [%t] which is not the expected value [%s]", path, r.Bool(), expected)
+	}
+	return nil
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseIsNull(path string) error {
+	return d.jsonPathOfCCResponseIsOfType(path, jsonTypeNull)
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseIsOfType(path, expectedType string) error {
+	r, err := d.jsonPathOfCCResponse(path)
+	if err != nil {
+		return err
+	}
+
+	if t := jsonType(r); t != expectedType {
+		return errors.Errorf("JSON path [%s] resolves to [%s] of type [%s] which is not the expected type [%s]", path, r.Raw, t, expectedType)
+	}
+	return nil
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseExists(path string) error {
+	_, err := d.jsonPathOfCCResponse(path)
+	return err
+}
+
+func (d *CommonSteps) jsonPathOfCCResponseDoesNotExist(path string) error {
+	queryValue := d.BDDContext.Vars().Response()
+	if r := gjson.Get(queryValue, path); r.Exists() {
+		return errors.Errorf("expecting JSON path [%s] to not exist but it resolves to [%s]", path, r.Raw)
+	}
+	return nil
+}
+
+// jsonPathOfCCResponse returns the value at the given JSON path of the response or an error if the path does not exist
+func (d *CommonSteps) jsonPathOfCCResponse(path string) (gjson.Result, error) {
+	queryValue := d.BDDContext.Vars().Response()
+	r := gjson.Get(queryValue, path)
+	if !r.Exists() {
+		return r, errors.Errorf("JSON path [%s] does not exist in %s", path, queryValue)
+	}
+
+	logger.Infof("Path [%s] of JSON %s resolves to %s", path, queryValue, r.Raw)
+	return r, nil
+}
+
+// compareJSONPathOfCCResponse resolves the expected values and compares them numerically with the value at the given JSON path
+func (d *CommonSteps) compareJSONPathOfCCResponse(path string, compare func(value float64, bounds []float64) bool, desc string, expected ...string) error {
+	r, err := d.jsonPathOfCCResponse(path)
+	if err != nil {
+		return err
+	}
+
+	value, err := numericValue(r)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid value at JSON path [%s]", path)
+	}
+
+	resolved, err := d.BDDContext.Vars().ResolveAll(expected)
+	if err != nil {
+		return err
+	}
+
+	var bounds []float64
+	for _, e := range resolved {
+		b, err := strconv.ParseFloat(e, 64)
+		if err != nil {
+			return errors.Wrapf(err, "expected value [%s] is not a number", e)
+		}
+		bounds = append(bounds, b)
+	}
+
+	if !compare(value, bounds) {
+		return errors.Errorf("JSON path [%s] resolves to [%s] which is not %s %v", path, r.Raw, desc, resolved)
+	}
+	return nil
+}
+
+// numericValue returns the numeric value of the given result. Numbers encoded as JSON strings are also accepted.
+func numericValue(r gjson.Result) (float64, error) {
+	switch r.Type {
+	case gjson.Number:
+		return r.Num, nil
+	case gjson.String:
+		value, err := strconv.ParseFloat(r.Str, 64)
+		if err != nil {
+			return 0, errors.Errorf("[%s] is not a number", r.Str)
+		}
+		return value, nil
+	default:
+		return 0, errors.Errorf("[%s] is not a number", r.Raw)
+	}
+}
+
+// jsonType returns the JSON type of the given result
+func jsonType(r gjson.Result) string {
+	switch r.Type {
+	case gjson.String:
+		return jsonTypeString
+	case gjson.Number:
+		return jsonTypeNumber
+	case gjson.True, gjson.False:
+		return jsonTypeBoolean
+	case gjson.JSON:
+		if r.IsArray() {
+			return jsonTypeArray
+		}
+		return jsonTypeObject
+	default:
+		return jsonTypeNull
+	}
+}
+
+func checkMatches(desc, value, expr string) error {
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid regular expression [%s]", expr)
+	}
+
+	if !regex.MatchString(value) {
+		return errors.Errorf("%s [%s] does not match [%s]", desc, value, expr)
+	}
+	return nil
+}
+
+// checkJSONEquals checks that the given JSON documents are equal, ignoring the order of fields and whitespace.
+// Numbers are compared by their exact values so that large integers don't lose precision and numbers with
+// different representations are equal, e.g. 1 and 1.0 or 100 and 1e2.
+func checkJSONEquals(actual, expected string) error {
+	expectedValue, err := unmarshalJSON(expected)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid expected JSON: %s", expected)
+	}
+	actualValue, err := unmarshalJSON(actual)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid JSON: %s", actual)
+	}
+
+	if !jsonValuesEqual(actualValue, expectedValue) {
+		return errors.Errorf("JSON %s is not equal to the expected JSON %s", actual, expected)
+	}
+	return nil
+}
+
+// jsonValuesEqual returns true if the given unmarshalled JSON values are equal (see checkJSONEquals)
+func jsonValuesEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		return ok && jsonNumbersEqual(av, bv)
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !jsonValuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonValuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// jsonNumbersEqual compares the exact values of the given numbers
+func jsonNumbersEqual(a, b json.Number) bool {
+	ar, ok := new(big.Rat).SetString(a.String())
+	if !ok {
+		return a == b
+	}
+	br, ok := new(big.Rat).SetString(b.String())
+	if !ok {
+		return a == b
+	}
+	return ar.Cmp(br) == 0
+}
+
+// unmarshalJSON unmarshals the given JSON document. Numbers are unmarshalled as json.Number.
+func unmarshalJSON(doc string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	return value, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseAssertions(t *testing.T) {
+	d := &CommonSteps{BDDContext: &BDDContext{vars: NewVarStore()}}
+	d.BDDContext.Vars().Set("min", "10")
+	d.BDDContext.Vars().Set("owner", "alice")
+	d.BDDContext.Vars().SetResponse(`{"owner":"alice","count":12,"price":"9.5","active":true,"note":null,"tags":["a","b"],"meta":{"x":1,"y":[2,3]}}`)
+
+	assert.NoError(t, d.responseMatches(`"owner":"\w+"`))
+	assert.Error(t, d.responseMatches(`"owner":\d+`))
+	assert.NoError(t, d.responseEqualsJSON(`{"meta":{"y":[2,3],"x":1},"tags":["a","b"],"note":null,"active":true,"price":"9.5","count":12,"owner":"${owner}"}`))
+	assert.Error(t, d.responseEqualsJSON(`{"owner":"alice"}`))
+
+	assert.NoError(t, d.jsonPathOfCCResponseEquals("count", "12"))
+	assert.NoError(t, d.jsonPathOfCCResponseEquals("active", "true"))
+	assert.NoError(t, d.jsonPathOfCCResponseEquals("owner", "${owner}"))
+	assert.NoError(t, d.jsonPathOfCCResponseMatches("owner", "^a.*e$"))
+	assert.NoError(t, d.jsonPathOfCCResponseEqualsJSON("meta", `{"y":[2,3],"x":1}`))
+	assert.Error(t, d.jsonPathOfCCResponseEqualsJSON("meta", `{"y":[3,2],"x":1}`))
+
+	assert.NoError(t, d.jsonPathOfCCResponseIsGreaterThan("count", "${min}"))
+	assert.Error(t, d.jsonPathOfCCResponseIsGreaterThan("count", "12"))
+	assert.NoError(t, d.jsonPathOfCCResponseIsLessThan("price", "10"))
+	assert.NoError(t, d.jsonPathOfCCResponseIsBetween("count", "12", "13"))
+	assert.Error(t, d.jsonPathOfCCResponseIsBetween("count", "1", "${min}"))
+	assert.Error(t, d.jsonPathOfCCResponseIsGreaterThan("owner", "1"))
+	assert.Error(t, d.jsonPathOfCCResponseIsGreaterThan("count", "abc"))
+
+	assert.NoError(t, d.jsonPathOfCCResponseIsBoolean("active", "true"))
+	assert.Error(t, d.jsonPathOfCCResponseIsBoolean("active", "false"))
+	assert.Error(t, d.jsonPathOfCCResponseIsBoolean("owner", "true"))
+	d.BDDContext.Vars().Set("active", "true")
+	assert.NoError(t, d.jsonPathOfCCResponseIsBoolean("active", "${active}"))
+	assert.Error(t, d.jsonPathOfCCResponseIsBoolean("active", "${owner}"))
+	assert.Error(t, d.jsonPathOfCCResponseIsBoolean("active", "${undefined}"))
+	assert.NoError(t, d.jsonPathOfCCResponseIsNull("note"))
+	assert.Error(t, d.jsonPathOfCCResponseIsNull("owner"))
+	assert.Error(t, d.jsonPathOfCCResponseIsNull("missing"))
+
+	assert.NoError(t, d.jsonPathOfCCResponseIsOfType("owner", jsonTypeString))
+	assert.NoError(t, d.jsonPathOfCCResponseIsOfType("count", jsonTypeNumber))
+	assert.NoError(t, d.jsonPathOfCCResponseIsOfType("active", jsonTypeBoolean))
+	assert.NoError(t, d.jsonPathOfCCResponseIsOfType("tags", jsonTypeArray))
+	assert.NoError(t, d.jsonPathOfCCResponseIsOfType("meta", jsonTypeObject))
+	assert.Error(t, d.jsonPathOfCCResponseIsOfType("meta", jsonTypeArray))
+
+	assert.NoError(t, d.jsonPathOfCCResponseExists("meta.y"))
+	assert.NoError(t, d.jsonPathOfCCResponseExists("note"))
+	assert.Error(t, d.jsonPathOfCCResponseExists("meta.z"))
+	assert.NoError(t, d.jsonPathOfCCResponseDoesNotExist("meta.z"))
+	assert.Error(t, d.jsonPathOfCCResponseDoesNotExist("tags"))
+}
+
+func TestCheckJSONEquals(t *testing.T) {
+	assert.NoError(t, checkJSONEquals(`{"id":9007199254740993,"a":[1,2.5]}`, ` {"a":[1, 2.5], "id":9007199254740993} `))
+	assert.Error(t, checkJSONEquals(`{"id":9007199254740993}`, `{"id":9007199254740992}`))
+	assert.Error(t, checkJSONEquals(`{"id":12345678901234567890123}`, `{"id":12345678901234567890124}`))
+	assert.NoError(t, checkJSONEquals(`{"id":1,"a":[100,0.5]}`, `{"id":1.0,"a":[1e2,5E-1]}`))
+	assert.NoError(t, checkJSONEquals(`{"id":0}`, `{"id":-0.0}`))
+	assert.Error(t, checkJSONEquals(`{"id":1}`, `{"id":1.0000000000000000001}`))
+	assert.Error(t, checkJSONEquals(`{"id":1,"a":2}`, `{"id":1,"b":2}`))
+	assert.Error(t, checkJSONEquals(`[1,2]`, `[1,2,3]`))
+	assert.Error(t, checkJSONEquals(`{"id":null}`, `{"id":false}`))
+	assert.Error(t, checkJSONEquals(`{"id":1}`, `{"id":"1"}`))
+	assert.Error(t, checkJSONEquals(`{"id":1}`, `{"id":1} {}`))
+	assert.Error(t, checkJSONEquals(`{"id":1`, `{"id":1}`))
+}
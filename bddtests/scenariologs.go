@@ -0,0 +1,121 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var invalidFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ContainerLogsSince returns the output of the container for the given compose service written since the given time
+func (c *Composition) ContainerLogsSince(composeService string, since time.Time) (string, error) {
+	containerID, err := c.containerIDForService(composeService)
+	if err != nil {
+		return "", err
+	}
+	return c.containerLogs(containerID, since)
+}
+
+// CaptureLogs writes the output of each of the project's containers written since the given time to a separate
+// file (named after the compose service or container) in the given directory
+func (c *Composition) CaptureLogs(dir string, since time.Time) error {
+	if err := c.refreshContainerList(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Error creating log directory '%s':  %s", dir, err)
+	}
+
+	for _, apiContainer := range c.apiContainers {
+		name := apiContainer.Labels[composeServiceLabel]
+		if name == "" && len(apiContainer.Names) > 0 {
+			name = strings.TrimPrefix(apiContainer.Names[0], "/")
+		}
+		if name == "" {
+			name = apiContainer.ID
+		}
+
+		logs, err := c.containerLogs(apiContainer.ID, since)
+		if err != nil {
+			return err
+		}
+
+		logFile := filepath.Join(dir, sanitizeFileName(name)+".log")
+		if err := ioutil.WriteFile(logFile, []byte(logs), 0644); err != nil {
+			return fmt.Errorf("Error writing log file '%s':  %s", logFile, err)
+		}
+	}
+
+	return nil
+}
+
+// filterLogsSince removes the lines that were written before the given time from logs which were retrieved with
+// timestamps. The timestamps are also removed from the returned lines.
+func filterLogsSince(logs string, since time.Time) string {
+	var filtered []string
+	include := false
+	for _, line := range strings.Split(logs, "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			// Not a timestamped line so include it only if the preceding line was included
+			if include {
+				filtered = append(filtered, line)
+			}
+			continue
+		}
+
+		include = !timestamp.Before(since)
+		if !include {
+			continue
+		}
+
+		if len(fields) > 1 {
+			filtered = append(filtered, fields[1])
+		} else {
+			filtered = append(filtered, "")
+		}
+	}
+
+	if len(filtered) == 0 {
+		return ""
+	}
+	return strings.Join(filtered, "\n") + "\n"
+}
+
+func sanitizeFileName(name string) string {
+	name = strings.Trim(invalidFileNameChars.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// scenarioLogDir returns a directory, under the given base directory, named after the given feature and scenario.
+// A numeric suffix is added if the directory already exists (for example, for each example of a scenario outline).
+func scenarioLogDir(baseDir, feature, scenario string) string {
+	dir := filepath.Join(baseDir, sanitizeFileName(feature), sanitizeFileName(scenario))
+	candidate := dir
+	for i := 2; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", dir, i)
+	}
+}
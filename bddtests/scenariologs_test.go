@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterLogsSince(t *testing.T) {
+	logs := "2020-01-01T10:00:00.100000000Z line 1\n" +
+		"2020-01-01T10:00:00.500000000Z line 2\n" +
+		"continuation of line 2\n" +
+		"2020-01-01T10:00:01.000000000Z line 3\n"
+
+	since, err := time.Parse(time.RFC3339Nano, "2020-01-01T10:00:00.200Z")
+	require.NoError(t, err)
+
+	assert.Equal(t, "line 2\ncontinuation of line 2\nline 3\n", filterLogsSince(logs, since))
+	assert.Equal(t, "line 1\nline 2\ncontinuation of line 2\nline 3\n", filterLogsSince(logs, time.Time{}))
+	assert.Empty(t, filterLogsSince(logs, since.Add(time.Hour)))
+}
+
+func TestScenarioLogDir(t *testing.T) {
+	assert.Equal(t, "peer0.org1.example.com", sanitizeFileName("peer0.org1.example.com"))
+	assert.Equal(t, "Invoke_chaincode_on_mychannel", sanitizeFileName(" Invoke chaincode on 'mychannel' "))
+	assert.Equal(t, "unnamed", sanitizeFileName("***"))
+
+	baseDir, err := ioutil.TempDir("", "logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(baseDir)
+
+	dir := scenarioLogDir(baseDir, "My feature", "My scenario")
+	assert.Equal(t, filepath.Join(baseDir, "My_feature", "My_scenario"), dir)
+
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	assert.Equal(t, filepath.Join(baseDir, "My_feature", "My_scenario_2"), scenarioLogDir(baseDir, "My feature", "My scenario"))
+}
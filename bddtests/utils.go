@@ -70,7 +70,8 @@ func HasPrimaryPeerJoinedChannel(channelID string, client *resmgmt.Client, orgUs
 	return foundChannel, nil
 }
 
-// GetByteArgs is a utility which converts []string to [][]bytes
+// GetByteArgs is a utility which converts []string to [][]bytes. The args are converted as is;
+// use DecodeArgs in order to decode args with an encoding prefix.
 func GetByteArgs(argsArray []string) [][]byte {
 	txArgs := make([][]byte, len(argsArray))
 	for i, val := range argsArray {
@@ -136,18 +137,36 @@ func newPolicy(policyString string) (*fabricCommon.SignaturePolicyEnvelope, erro
 //	Then:
 //		"${arr1[0]_arr1[1]_arr1[2]}" = "value1_value2_value3"
 //
+// Example 3: Functions
+// 	Given:
+// 		vars = {
+// 			"var1": "value1",
+// 			"json1": `{"field1":{"field2":"value2"}}`,
+// 			}
+//	Then:
+//		"${base64(var1)}" = "dmFsdWUx"
+//		"${json(json1, "field1.field2")}" = "value2"
+//		"${base64("value1")}" = "dmFsdWUx"
+//
+// See resolveFuncs for the list of supported functions.
+//
+// Example 4: Nested references, default values and escaping
+// 	Given:
+// 		vars = {
+// 			"arr1": "value1,value2,value3",
+// 			"idx": "1",
+// 			}
+//	Then:
+//		"${arr1[${idx}]}" = "value2"
+//		"${var3:-default}" = "default"
+//		"$${var1}" = "${var1}"
+//
+// Variable values may themselves contain variable references, which are also resolved. An error is
+// returned if a variable refers back to itself (directly or indirectly).
+//
 func Resolve(vars map[string]string, arg string) (string, error) {
-	for {
-		str, err := doResolve(vars, arg)
-		if err != nil {
-			return arg, err
-		}
-		if str == arg {
-			// Done
-			return str, nil
-		}
-		arg = str
-	}
+	r := &resolver{vars: vars, arg: arg}
+	return r.resolve(arg, nil)
 }
 
 func ResolveAll(vars map[string]string, args []string) ([]string, error) {
@@ -162,56 +181,145 @@ func ResolveAll(vars map[string]string, args []string) ([]string, error) {
 	return argArr, nil
 }
 
-func doResolve(vars map[string]string, arg string) (string, error) {
-	if len(arg) <= 3 {
-		return arg, nil
+const (
+	varOpen       = "${"
+	varClose      = "}"
+	escapedOpen   = "$${"
+	defaultMarker = ":-"
+)
+
+// resolver resolves the variable references within an arg. The arg is retained for error messages.
+type resolver struct {
+	vars map[string]string
+	arg  string
+}
+
+// resolve replaces all variable references in str. The stack holds the names of the variables
+// which are currently being resolved and is used to detect cycles.
+func (r *resolver) resolve(str string, stack []string) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(str); {
+		switch {
+		case strings.HasPrefix(str[i:], escapedOpen):
+			result.WriteString(varOpen)
+			i += len(escapedOpen)
+		case strings.HasPrefix(str[i:], varOpen):
+			end, err := r.findClose(str, i+len(varOpen))
+			if err != nil {
+				return r.arg, err
+			}
+
+			// Resolve nested references within the expression first
+			expr, err := r.resolve(str[i+len(varOpen):end], stack)
+			if err != nil {
+				return r.arg, err
+			}
+
+			value, err := r.evaluate(expr, stack)
+			if err != nil {
+				return r.arg, err
+			}
+
+			result.WriteString(value)
+			i = end + len(varClose)
+		default:
+			result.WriteByte(str[i])
+			i++
+		}
 	}
+	return result.String(), nil
+}
+
+// findClose returns the index of the } which closes the expression starting at the given index,
+// taking nested expressions into account
+func (r *resolver) findClose(str string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(str); {
+		switch {
+		case strings.HasPrefix(str[i:], escapedOpen):
+			i += len(escapedOpen)
+		case strings.HasPrefix(str[i:], varOpen):
+			depth++
+			i += len(varOpen)
+		case strings.HasPrefix(str[i:], varClose):
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+			i += len(varClose)
+		default:
+			i++
+		}
+	}
+	return 0, errors.Errorf("expecting } for arg '%s'", r.arg)
+}
 
-	open := strings.Index(arg, "${")
-	if open == -1 {
-		return arg, nil
+// evaluate returns the value of the given expression, which may be a variable name, an array
+// reference, a function invocation or any of these followed by a default value
+func (r *resolver) evaluate(expr string, stack []string) (string, error) {
+	// A default marker within the arguments of a function is part of the argument
+	if i := strings.Index(expr, defaultMarker); i >= 0 && !strings.Contains(expr[0:i], "(") {
+		value, err := r.evaluate(expr[0:i], stack)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return expr[i+len(defaultMarker):], nil
+		}
+		return value, nil
 	}
 
-	close := strings.Index(arg, "}")
-	if close == -1 {
-		return arg, errors.Errorf("expecting } for arg '%s'", arg)
+	if isFuncExpr(expr) {
+		return resolveFuncExpr(r.vars, expr, r.arg)
 	}
 
-	// Check for array
-	varName := arg[open+2 : close]
-	ob := strings.Index(varName, "[")
+	ob := strings.Index(expr, "[")
 	if ob == -1 {
 		// Not an array
-		return replace(arg, vars[varName], open, close), nil
+		return r.varValue(expr, stack)
 	}
 
-	cb := strings.Index(varName, "]")
-	if cb == -1 {
-		return arg, errors.Errorf("invalid arg '%s'", arg)
+	cb := strings.Index(expr, "]")
+	if cb == -1 || cb < ob {
+		return "", errors.Errorf("invalid arg '%s'", r.arg)
 	}
 
-	arrVar := varName[0:ob]
-	values := vars[arrVar]
+	values, err := r.varValue(expr[0:ob], stack)
+	if err != nil {
+		return "", err
+	}
 
 	if values == "" {
-		return replace(arg, "", open, close), nil
+		return "", nil
 	}
 
-	index := varName[ob+1 : cb]
+	index := expr[ob+1 : cb]
 
 	vals := strings.Split(values, ",")
 	i, err := strconv.Atoi(index)
 	if err != nil {
-		return arg, errors.Errorf("invalid index [%s] for arg '%s'", index, arg)
+		return "", errors.Errorf("invalid index [%s] for arg '%s'", index, r.arg)
 	}
 
-	if i >= len(vals) {
-		return arg, errors.Errorf("index [%d] out of range for arg '%s'", i, arg)
+	if i < 0 || i >= len(vals) {
+		return "", errors.Errorf("index [%d] out of range for arg '%s'", i, r.arg)
 	}
 
-	return replace(arg, vals[i], open, close), nil
+	return vals[i], nil
 }
 
-func replace(arg, value string, open, close int) string {
-	return arg[0:open] + value + arg[close+1:]
+// varValue returns the value of the given variable with any variable references in the value resolved
+func (r *resolver) varValue(varName string, stack []string) (string, error) {
+	for _, name := range stack {
+		if name == varName {
+			return "", errors.Errorf("cyclic variable reference [%s] for arg '%s'", strings.Join(append(stack, varName), " -> "), r.arg)
+		}
+	}
+
+	value := r.vars[varName]
+	if !strings.Contains(value, varOpen) {
+		return value, nil
+	}
+
+	return r.resolve(value, append(stack[:len(stack):len(stack)], varName))
 }
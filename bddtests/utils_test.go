@@ -7,7 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package bddtests
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -86,3 +88,174 @@ func TestResolveAll(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, []string{"val1", "val2"}, args)
 }
+
+func TestResolveFunctions(t *testing.T) {
+	vars := map[string]string{
+		"var1":  "value1",
+		"arr":   "v1,v2,v3",
+		"json1": `{"field1":{"field2":"value2"},"arr":[1,2]}`,
+		"path":  "field1.field2",
+	}
+
+	t.Run("uuid", func(t *testing.T) {
+		v1, err := Resolve(vars, "${uuid()}")
+		require.NoError(t, err)
+		assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", v1)
+
+		v2, err := Resolve(vars, "${uuid()}")
+		require.NoError(t, err)
+		assert.NotEqual(t, v1, v2)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		v1, err := Resolve(vars, "key_${random(10)}")
+		require.NoError(t, err)
+		assert.Regexp(t, "^key_[a-z0-9]{10}$", v1)
+
+		v2, err := Resolve(vars, "key_${random(10)}")
+		require.NoError(t, err)
+		assert.NotEqual(t, v1, v2)
+
+		_, err = Resolve(vars, "${random(x)}")
+		assert.EqualError(t, err, "error invoking function [random] for arg '${random(x)}': expecting a positive integer but got [x]")
+	})
+
+	t.Run("now", func(t *testing.T) {
+		v, err := Resolve(vars, "${now()}")
+		require.NoError(t, err)
+		_, err = time.Parse(time.RFC3339, v)
+		assert.NoError(t, err)
+	})
+
+	t.Run("base64 and sha256", func(t *testing.T) {
+		v, err := Resolve(vars, "${base64(var1)}")
+		require.NoError(t, err)
+		assert.Equal(t, "dmFsdWUx", v)
+
+		v, err = Resolve(vars, `${base64("value1")}`)
+		require.NoError(t, err)
+		assert.Equal(t, "dmFsdWUx", v)
+
+		v, err = Resolve(vars, "${sha256(var1)}")
+		require.NoError(t, err)
+		assert.Equal(t, "3c9683017f9e4bf33d0fbedd26bf143fd72de9b9dd145441b75f0604047ea28e", v)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		require.NoError(t, os.Setenv("BDD_TEST_ENV_VAR", "env_value"))
+		defer os.Unsetenv("BDD_TEST_ENV_VAR")
+
+		v, err := Resolve(vars, "X_${env(BDD_TEST_ENV_VAR)}")
+		require.NoError(t, err)
+		assert.Equal(t, "X_env_value", v)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		v, err := Resolve(vars, `${json(json1, "field1.field2")}`)
+		require.NoError(t, err)
+		assert.Equal(t, "value2", v)
+
+		v, err = Resolve(vars, `${json(json1, path)}`)
+		require.NoError(t, err)
+		assert.Equal(t, "value2", v)
+
+		_, err = Resolve(vars, `${json(var1, "field1")}`)
+		assert.EqualError(t, err, `error invoking function [json] for arg '${json(var1, "field1")}': value of [var1] is not valid JSON`)
+	})
+
+	t.Run("len", func(t *testing.T) {
+		v, err := Resolve(vars, "${len(arr)}")
+		require.NoError(t, err)
+		assert.Equal(t, "3", v)
+
+		v, err = Resolve(vars, "${len(undefined)}")
+		require.NoError(t, err)
+		assert.Equal(t, "0", v)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := Resolve(vars, "${unknown(var1)}")
+		assert.EqualError(t, err, "unknown function [unknown] for arg '${unknown(var1)}'")
+
+		_, err = Resolve(vars, "${base64(var1, var2)}")
+		assert.EqualError(t, err, "error invoking function [base64] for arg '${base64(var1, var2)}': expecting 1 argument(s) but got 2")
+
+		_, err = Resolve(vars, `${base64("var1)}`)
+		assert.EqualError(t, err, `invalid arguments for function [base64] for arg '${base64("var1)}': unterminated quote in ["var1]`)
+	})
+}
+
+func TestResolveNested(t *testing.T) {
+	vars := map[string]string{
+		"var1": "val1",
+		"arr":  "v1,v12,v123",
+		"idx":  "2",
+		"name": "var1",
+		"ref":  "X_${var1}",
+		"self": "${self}",
+		"a":    "${b}",
+		"b":    "${c}",
+		"c":    "${a}",
+	}
+
+	t.Run("Adjacent", func(t *testing.T) {
+		v, err := Resolve(vars, "${var1}${var1}")
+		require.NoError(t, err)
+		assert.Equal(t, "val1val1", v)
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		v, err := Resolve(vars, "${arr[${idx}]}")
+		require.NoError(t, err)
+		assert.Equal(t, "v123", v)
+
+		v, err = Resolve(vars, "${${name}}")
+		require.NoError(t, err)
+		assert.Equal(t, "val1", v)
+
+		v, err = Resolve(vars, "${ref}")
+		require.NoError(t, err)
+		assert.Equal(t, "X_val1", v)
+	})
+
+	t.Run("Escaped", func(t *testing.T) {
+		v, err := Resolve(vars, "$${var1}_${var1}")
+		require.NoError(t, err)
+		assert.Equal(t, "${var1}_val1", v)
+
+		v, err = Resolve(vars, "${arr[${idx}]}_$${arr[0]}")
+		require.NoError(t, err)
+		assert.Equal(t, "v123_${arr[0]}", v)
+	})
+
+	t.Run("Default value", func(t *testing.T) {
+		v, err := Resolve(vars, "${var1:-default}")
+		require.NoError(t, err)
+		assert.Equal(t, "val1", v)
+
+		v, err = Resolve(vars, "${undefined:-default}")
+		require.NoError(t, err)
+		assert.Equal(t, "default", v)
+
+		v, err = Resolve(vars, "${undefined:-${var1}}")
+		require.NoError(t, err)
+		assert.Equal(t, "val1", v)
+
+		v, err = Resolve(vars, "${undefined[1]:-}")
+		require.NoError(t, err)
+		assert.Equal(t, "", v)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		_, err := Resolve(vars, "${self}")
+		assert.EqualError(t, err, "cyclic variable reference [self -> self] for arg '${self}'")
+
+		_, err = Resolve(vars, "X_${a}")
+		assert.EqualError(t, err, "cyclic variable reference [a -> b -> c -> a] for arg 'X_${a}'")
+	})
+
+	t.Run("Unterminated", func(t *testing.T) {
+		_, err := Resolve(vars, "${arr[${idx}]")
+		assert.EqualError(t, err, "expecting } for arg '${arr[${idx}]'")
+	})
+}
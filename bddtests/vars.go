@@ -0,0 +1,225 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"sync"
+)
+
+// VarScope is the scope of a variable
+type VarScope string
+
+const (
+	// ScenarioScope variables are cleared at the end of each scenario
+	ScenarioScope VarScope = "scenario"
+	// FeatureScope variables are cleared at the start of each feature
+	FeatureScope VarScope = "feature"
+	// SuiteScope variables are never cleared
+	SuiteScope VarScope = "suite"
+)
+
+// VarStore holds variables in scenario, feature and suite scopes along with the most recent response
+// and the most recent expected error.
+// When a variable is looked up, the scenario scope takes precedence over the feature scope which takes
+// precedence over the suite scope. VarStore is safe for concurrent access.
+type VarStore struct {
+	mutex     sync.RWMutex
+	scopes    map[VarScope]map[string]string
+	response  string
+	lastError error
+}
+
+// NewVarStore returns a new, empty variable store
+func NewVarStore() *VarStore {
+	return &VarStore{
+		scopes: map[VarScope]map[string]string{
+			ScenarioScope: make(map[string]string),
+			FeatureScope:  make(map[string]string),
+			SuiteScope:    make(map[string]string),
+		},
+	}
+}
+
+// Set sets the value of the given scenario-scoped variable
+func (s *VarStore) Set(varName, value string) {
+	s.SetInScope(ScenarioScope, varName, value)
+}
+
+// SetInScope sets the value of the variable in the given scope
+func (s *VarStore) SetInScope(scope VarScope, varName, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	vars, ok := s.scopes[scope]
+	if !ok {
+		panic(fmt.Sprintf("invalid variable scope: %s", scope))
+	}
+	vars[varName] = value
+}
+
+// Get returns the value of the given variable from the narrowest scope in which it is defined.
+// Returns true if the variable exists; false otherwise
+func (s *VarStore) Get(varName string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, scope := range []VarScope{ScenarioScope, FeatureScope, SuiteScope} {
+		if value, ok := s.scopes[scope][varName]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Vars returns a copy of all variables. Scenario-scoped variables override feature-scoped variables
+// which override suite-scoped variables with the same name.
+func (s *VarStore) Vars() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	vars := make(map[string]string)
+	for _, scope := range []VarScope{SuiteScope, FeatureScope, ScenarioScope} {
+		for name, value := range s.scopes[scope] {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// Resolve resolves all variables within the given arg
+func (s *VarStore) Resolve(arg string) (string, error) {
+	return Resolve(s.Vars(), arg)
+}
+
+// ResolveAll resolves all variables within each of the given args
+func (s *VarStore) ResolveAll(args []string) ([]string, error) {
+	return ResolveAll(s.Vars(), args)
+}
+
+// ResolveAllVars splits the given comma-separated string (see SplitArgs) and resolves all variables within each of the values
+func (s *VarStore) ResolveAllVars(args string) ([]string, error) {
+	return s.ResolveAll(SplitArgs(args))
+}
+
+// Response returns the most recent response
+func (s *VarStore) Response() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.response
+}
+
+// SetResponse sets the most recent response
+func (s *VarStore) SetResponse(response string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.response = response
+}
+
+// ClearResponse clears the most recent response
+func (s *VarStore) ClearResponse() {
+	s.SetResponse("")
+}
+
+// LastError returns the error returned by the most recent step which was expected to fail
+func (s *VarStore) LastError() error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.lastError
+}
+
+// SetLastError sets the error returned by the most recent step which was expected to fail
+func (s *VarStore) SetLastError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastError = err
+}
+
+// Clear removes all variables in the given scope. The response and last error are also cleared
+// when the scenario scope is cleared.
+func (s *VarStore) Clear(scope VarScope) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scopes[scope] = make(map[string]string)
+	if scope == ScenarioScope {
+		s.response = ""
+		s.lastError = nil
+	}
+}
+
+// sharedVars is the variable store used by the package-level variable functions (SetVar, GetVar, etc.)
+var sharedVars = NewVarStore()
+
+// SharedVarStore returns the variable store which is used by the package-level variable functions. A BDDContext
+// only uses the shared store if it is given the store explicitly (see BDDContext.SetVarStore), in which case the
+// suite scope of the shared store is shared by all such contexts.
+func SharedVarStore() *VarStore {
+	return sharedVars
+}
+
+// ClearResponse clears the response in the shared variable store
+func ClearResponse() {
+	sharedVars.ClearResponse()
+}
+
+// GetResponse returns the most recent response in the shared variable store
+func GetResponse() string {
+	return sharedVars.Response()
+}
+
+// SetResponse sets the response in the shared variable store
+func SetResponse(response string) {
+	sharedVars.SetResponse(response)
+}
+
+// SetVar sets the value for the given variable in the shared variable store
+func SetVar(varName, value string) {
+	sharedVars.Set(varName, value)
+}
+
+// GetVar gets the value for the given variable from the shared variable store
+// Returns true if the variable exists; false otherwise
+func GetVar(varName string) (string, bool) {
+	return sharedVars.Get(varName)
+}
+
+// ResolveAllVars returns a slice of strings from the given comma-separated string (see SplitArgs).
+// Each string is resolved for variables.
+// Resolve resolves all variables within the given arg
+//
+// Example 1: Simple variable
+//
+//	Given:
+//		vars = {
+//			"var1": "value1",
+//			"var2": "value2",
+//			}
+//	Then:
+//		"${var1}" = "value1"
+//		"X_${var1}_${var2} = "X_value1_value2
+//
+// Example 2: Array variable
+//
+//	Given:
+//		vars = {
+//			"arr1": "value1,value2,value3",
+//			}
+//	Then:
+//		"${arr1[0]_arr1[1]_arr1[2]}" = "value1_value2_value3"
+func ResolveAllVars(args string) ([]string, error) {
+	return sharedVars.ResolveAllVars(args)
+}
+
+// ResolveVars resolves all variables within the given arg using the shared variable store
+func ResolveVars(arg string) (string, error) {
+	return sharedVars.Resolve(arg)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bddtests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarStore(t *testing.T) {
+	s := NewVarStore()
+
+	s.SetInScope(SuiteScope, "var1", "suite1")
+	s.SetInScope(SuiteScope, "var2", "suite2")
+	s.SetInScope(FeatureScope, "var2", "feature2")
+	s.SetInScope(FeatureScope, "var3", "feature3")
+	s.Set("var3", "scenario3")
+	s.SetResponse("response")
+
+	value, ok := s.Get("var1")
+	assert.True(t, ok)
+	assert.Equal(t, "suite1", value)
+
+	v, err := s.Resolve("${var1}_${var2}_${var3}")
+	require.NoError(t, err)
+	assert.Equal(t, "suite1_feature2_scenario3", v)
+
+	s.Clear(ScenarioScope)
+	assert.Empty(t, s.Response())
+	value, ok = s.Get("var3")
+	assert.True(t, ok)
+	assert.Equal(t, "feature3", value)
+
+	s.Clear(FeatureScope)
+	v, err = s.Resolve("${var1}_${var2}_${var3}")
+	require.NoError(t, err)
+	assert.Equal(t, "suite1_suite2_", v)
+
+	_, ok = s.Get("var3")
+	assert.False(t, ok)
+
+	t.Run("Invalid scope", func(t *testing.T) {
+		assert.Panics(t, func() { s.SetInScope("invalid", "var1", "value") })
+	})
+
+	t.Run("Concurrent access", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("var%d", i)
+				s.Set(name, name)
+				_, err := s.Resolve("${" + name + "}")
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+		assert.Len(t, s.Vars(), 10)
+	})
+}
+
+func TestSharedVarStore(t *testing.T) {
+	shared, err := NewBDDContext(nil, "", "", "", nil, "", "")
+	require.NoError(t, err)
+	shared.SetVarStore(SharedVarStore())
+	isolated, err := NewBDDContext(nil, "", "", "", nil, "", "")
+	require.NoError(t, err)
+	other, err := NewBDDContext(nil, "", "", "", nil, "", "")
+	require.NoError(t, err)
+	require.False(t, isolated.Vars() == SharedVarStore())
+	require.False(t, isolated.Vars() == other.Vars())
+
+	SetVar("sharedVar", "value1")
+	defer SharedVarStore().Clear(ScenarioScope)
+
+	value, ok := shared.Vars().Get("sharedVar")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", value)
+
+	_, ok = isolated.Vars().Get("sharedVar")
+	assert.False(t, ok)
+
+	isolated.Vars().SetInScope(SuiteScope, "isolatedVar", "value2")
+	_, ok = GetVar("isolatedVar")
+	assert.False(t, ok)
+
+	_, ok = other.Vars().Get("isolatedVar")
+	assert.False(t, ok)
+}
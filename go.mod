@@ -4,6 +4,7 @@ require (
 	github.com/DATA-DOG/godog v0.7.13
 	github.com/containerd/continuity v0.0.0-20181003075958-be9bd761db19 // indirect
 	github.com/fsouza/go-dockerclient v1.3.0
+	github.com/golang/protobuf v1.3.2
 	github.com/hyperledger/fabric-protos-go v0.0.0-20190821180310-6b6ac9042dfd
 	github.com/hyperledger/fabric-sdk-go v1.0.0-beta1.0.20190930220855-cea2ffaf627c
 	github.com/magiconair/properties v1.8.0 // indirect